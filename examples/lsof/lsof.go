@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	sigar "github.com/cloudfoundry/gosigar"
+)
+
+const outputFormat = "%-15s %7s %-8s %5s %-8s %10s %s\n"
+
+func main() {
+	// lsof [-p pid]
+	var pidFilter int
+	flag.IntVar(&pidFilter, "p", 0, "Process ID to list open files for")
+	flag.Parse()
+
+	pids := sigar.ProcList{}
+	if pidFilter != 0 {
+		pids.List = []int{pidFilter}
+	} else {
+		pids.Get() //nolint:errcheck
+	}
+
+	fmt.Printf(outputFormat, "COMMAND", "PID", "USER", "FD", "TYPE", "OFFSET", "NAME")
+
+	for _, pid := range pids.List {
+		state := sigar.ProcState{}
+		cred := sigar.ProcCred{}
+		fds := sigar.ProcFdList{}
+
+		if err := state.Get(pid); err != nil {
+			continue
+		}
+		if err := cred.Get(pid); err != nil {
+			continue
+		}
+		if err := fds.Get(pid); err != nil {
+			continue
+		}
+
+		for _, fd := range fds.List {
+			fmt.Printf(outputFormat,
+				state.Name, strconv.Itoa(pid), cred.User,
+				strconv.Itoa(fd.Fd)+accessMode(fd.Flags), fd.Type,
+				strconv.FormatUint(fd.Pos, 10), fd.Target)
+		}
+	}
+}
+
+// accessMode mimics the lsof mode character for the O_ACCMODE bits.
+func accessMode(flags uint64) string {
+	switch flags & 03 {
+	case 0:
+		return "r"
+	case 1:
+		return "w"
+	default:
+		return "u"
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	sigar "github.com/cloudfoundry/gosigar"
+)
+
+func main() {
+	// pgrep [-f] [-l] [-u user] [-P ppid] [-s sid] [-g cgroup] [-O secs] [pattern]
+	var full, long bool
+	var user, cgroup string
+	var ppid, session, older int
+	flag.BoolVar(&full, "f", false, "Match the pattern against the full command line")
+	flag.BoolVar(&long, "l", false, "List the process name or command line")
+	flag.StringVar(&user, "u", "", "Only match processes of this effective user")
+	flag.IntVar(&ppid, "P", 0, "Only match children of this process")
+	flag.IntVar(&session, "s", 0, "Only match processes in this session")
+	flag.StringVar(&cgroup, "g", "", "Only match processes in this cgroup or below")
+	flag.IntVar(&older, "O", 0, "Only match processes older than this many seconds")
+	flag.Parse()
+
+	q := sigar.ProcQuery{}
+	if flag.NArg() > 0 {
+		re, err := regexp.Compile(flag.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "pgrep: %s\n", err)
+			os.Exit(2)
+		}
+		if full {
+			q.Cmdline(re)
+		} else {
+			q.Name(re)
+		}
+	}
+	if user != "" {
+		q.User(user)
+	}
+	if ppid != 0 {
+		q.Ppid(ppid)
+	}
+	if session != 0 {
+		q.Session(session)
+	}
+	if cgroup != "" {
+		q.CgroupPath(cgroup)
+	}
+	if older != 0 {
+		q.MinAge(time.Duration(older) * time.Second)
+	}
+
+	pids, err := q.Find()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pgrep: %s\n", err)
+		os.Exit(2)
+	}
+
+	found := false
+	for _, pid := range pids {
+		if pid == os.Getpid() {
+			continue
+		}
+		found = true
+
+		if !long {
+			fmt.Println(pid)
+			continue
+		}
+
+		name := ""
+		if full {
+			args := sigar.ProcArgs{}
+			args.Get(pid) //nolint:errcheck
+			name = strings.Join(args.List, " ")
+		} else {
+			state := sigar.ProcState{}
+			state.Get(pid) //nolint:errcheck
+			name = state.Name
+		}
+		fmt.Printf("%d %s\n", pid, name)
+	}
+
+	if !found {
+		os.Exit(1)
+	}
+}
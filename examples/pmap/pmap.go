@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	sigar "github.com/cloudfoundry/gosigar"
+)
+
+const outputFormat = "%-16s %8s %8s %8s %8s %-5s %s\n"
+
+func kbytes(size uint64) string {
+	return strconv.FormatUint(size/1024, 10)
+}
+
+func main() {
+	// pmap -x <pid>
+	flag.Parse()
+	pid, err := strconv.Atoi(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "usage: pmap <pid>\n")
+		os.Exit(1)
+	}
+
+	maps := sigar.ProcMapList{}
+	if err := maps.GetWithUsage(pid); err != nil {
+		fmt.Fprintf(os.Stderr, "pmap: %s\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf(outputFormat, "Address", "Kbytes", "RSS", "PSS", "Swap", "Mode", "Mapping")
+
+	var size, rss, pss, swap uint64
+	for _, m := range maps.List {
+		name := m.Path
+		if name == "" {
+			name = "[ anon ]"
+		}
+
+		fmt.Printf(outputFormat,
+			fmt.Sprintf("%016x", m.Start),
+			kbytes(m.Size()), kbytes(m.Rss), kbytes(m.Pss), kbytes(m.Swap),
+			m.Perms, name)
+
+		size += m.Size()
+		rss += m.Rss
+		pss += m.Pss
+		swap += m.Swap
+	}
+
+	fmt.Printf(outputFormat, "total kB", kbytes(size), kbytes(rss), kbytes(pss), kbytes(swap), "", "")
+}
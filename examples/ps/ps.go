@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 
 	sigar "github.com/cloudfoundry/gosigar"
@@ -10,27 +11,62 @@ func main() {
 	pids := sigar.ProcList{}
 	pids.Get() //nolint:errcheck
 
-	// ps -eo pid,ppid,stime,time,rss,state,comm
-	fmt.Print("    PID    PPID STIME     TIME   RSS S COMMAND\n")
+	// ps -eo user,pid,ppid,stime,tty,time,rss,state,comm
+	fmt.Print("USER         PID    PPID STIME TTY          TIME   RSS S COMMAND\n")
 
 	for _, pid := range pids.List {
-		state := sigar.ProcState{}
-		mem := sigar.ProcMem{}
-		time := sigar.ProcTime{}
-
-		if err := state.Get(pid); err != nil {
+		state, mem, time, ptty, err := procInfo(pid)
+		if err != nil {
 			continue
 		}
-		if err := mem.Get(pid); err != nil {
-			continue
+
+		user := "?"
+		cred := sigar.ProcCred{}
+		if err := cred.Get(pid); err == nil {
+			user = cred.User
 		}
-		if err := time.Get(pid); err != nil {
-			continue
+
+		tty := "?"
+		if ptty.Name != "" {
+			tty = ptty.Name
 		}
 
-		fmt.Printf("%7d %7d %s %s %5d %c %s\n",
-			pid, state.Ppid,
-			time.FormatStartTime(), time.FormatTotal(),
+		fmt.Printf("%-8s %7d %7d %s %-8s %s %5d %c %s\n",
+			user, pid, state.Ppid,
+			time.FormatStartTime(), tty, time.FormatTotal(),
 			mem.Resident/1024, state.State, state.Name)
 	}
 }
+
+// procInfo reads /proc/<pid>/stat once where ProcStat is available
+// and falls back to the individual getters elsewhere.
+func procInfo(pid int) (sigar.ProcState, sigar.ProcMem, sigar.ProcTime, sigar.ProcTty, error) {
+	stat := sigar.ProcStat{}
+	err := stat.Get(pid)
+	if err == nil {
+		mem := sigar.ProcMem{Resident: stat.Rss << 12}
+		return stat.ProcState(), mem, stat.ProcTime(), stat.ProcTty(), nil
+	}
+
+	state := sigar.ProcState{}
+	mem := sigar.ProcMem{}
+	time := sigar.ProcTime{}
+	tty := sigar.ProcTty{}
+
+	if !errors.Is(err, sigar.ErrNotImplemented) {
+		return state, mem, time, tty, err
+	}
+
+	if err := state.Get(pid); err != nil {
+		return state, mem, time, tty, err
+	}
+	if err := mem.Get(pid); err != nil {
+		return state, mem, time, tty, err
+	}
+	if err := time.Get(pid); err != nil {
+		return state, mem, time, tty, err
+	}
+	tty.Get(pid) //nolint:errcheck
+
+	return state, mem, time, tty, nil
+}
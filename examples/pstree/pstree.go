@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	sigar "github.com/cloudfoundry/gosigar"
+)
+
+func printTree(tree *sigar.ProcTree, pid int, depth int) {
+	state, _ := tree.State(pid)
+	fmt.Printf("%s%d %s\n", strings.Repeat("  ", depth), pid, state.Name)
+
+	for _, child := range tree.Children(pid) {
+		printTree(tree, child, depth+1)
+	}
+}
+
+func main() {
+	// pstree [pid]
+	flag.Parse()
+
+	tree := sigar.ProcTree{}
+	if err := tree.Get(); err != nil {
+		fmt.Fprintf(os.Stderr, "pstree: %s\n", err)
+		os.Exit(1)
+	}
+
+	if flag.NArg() == 0 {
+		for _, pid := range tree.Roots() {
+			printTree(&tree, pid, 0)
+		}
+		return
+	}
+
+	pid, err := strconv.Atoi(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "usage: pstree [pid]\n")
+		os.Exit(1)
+	}
+	if _, found := tree.State(pid); !found {
+		fmt.Fprintf(os.Stderr, "pstree: no such process %d\n", pid)
+		os.Exit(1)
+	}
+
+	printTree(&tree, pid, 0)
+
+	usage, err := tree.Usage(pid)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pstree: %s\n", err)
+		os.Exit(1)
+	}
+	cpu := sigar.ProcTime{Total: usage.CpuTime}
+	fmt.Printf("\n%d processes, %d threads, RSS %s, PSS %s, CPU %s\n",
+		usage.Procs, usage.Threads,
+		sigar.FormatSize(usage.Rss), sigar.FormatSize(usage.Pss),
+		cpu.FormatTotal())
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	sigar "github.com/cloudfoundry/gosigar"
+)
+
+const outputFormat = "%7s %-16s %10s\n"
+
+var metrics = []sigar.TopMetric{
+	sigar.TopByCpu, sigar.TopByRss, sigar.TopByPss,
+	sigar.TopByIO, sigar.TopBySwap, sigar.TopByFds,
+}
+
+func format(metric sigar.TopMetric, value float64) string {
+	switch metric {
+	case sigar.TopByCpu:
+		return strconv.FormatFloat(value*100, 'f', 1, 64) + "%"
+	case sigar.TopByIO:
+		return strings.TrimSpace(sigar.FormatSize(uint64(value))) + "/s"
+	case sigar.TopByFds:
+		return strconv.FormatFloat(value, 'f', 0, 64)
+	default:
+		return sigar.FormatSize(uint64(value))
+	}
+}
+
+func main() {
+	// top [-o cpu|rss|pss|io|swap|fds] [-m max] [-n iterations] [-d delay]
+	var order string
+	var max, iterations int
+	var delay time.Duration
+	flag.StringVar(&order, "o", "cpu", "Metric to sort by: cpu, rss, pss, io, swap or fds")
+	flag.IntVar(&max, "m", 10, "Maximum number of processes listed")
+	flag.IntVar(&iterations, "n", 1, "Number of iterations")
+	flag.DurationVar(&delay, "d", time.Second, "Delay between iterations")
+	flag.Parse()
+
+	top := sigar.TopProcesses{N: max, Metric: -1}
+	for _, metric := range metrics {
+		if metric.String() == order {
+			top.Metric = metric
+		}
+	}
+	if top.Metric < 0 {
+		fmt.Fprintf(os.Stderr, "top: unknown metric %s\n", order)
+		os.Exit(1)
+	}
+
+	// Rate metrics need a first scan to compare against
+	if top.Metric.IsRate() {
+		if err := top.Get(); err != nil {
+			fmt.Fprintf(os.Stderr, "top: %s\n", err)
+			os.Exit(1)
+		}
+		time.Sleep(delay)
+	}
+
+	for i := 0; i < iterations; i++ {
+		if i > 0 {
+			time.Sleep(delay)
+			fmt.Println()
+		}
+
+		if err := top.Get(); err != nil {
+			fmt.Fprintf(os.Stderr, "top: %s\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf(outputFormat, "PID", "COMMAND", top.Metric.String())
+		for _, proc := range top.List {
+			fmt.Printf(outputFormat, strconv.Itoa(proc.Pid), proc.Name, format(top.Metric, proc.Value))
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package sigar
+
+import (
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Capability is a Linux capability number, see capabilities(7).
+type Capability int
+
+const (
+	CapChown Capability = iota
+	CapDacOverride
+	CapDacReadSearch
+	CapFowner
+	CapFsetid
+	CapKill
+	CapSetgid
+	CapSetuid
+	CapSetpcap
+	CapLinuxImmutable
+	CapNetBindService
+	CapNetBroadcast
+	CapNetAdmin
+	CapNetRaw
+	CapIpcLock
+	CapIpcOwner
+	CapSysModule
+	CapSysRawio
+	CapSysChroot
+	CapSysPtrace
+	CapSysPacct
+	CapSysAdmin
+	CapSysBoot
+	CapSysNice
+	CapSysResource
+	CapSysTime
+	CapSysTtyConfig
+	CapMknod
+	CapLease
+	CapAuditWrite
+	CapAuditControl
+	CapSetfcap
+	CapMacOverride
+	CapMacAdmin
+	CapSyslog
+	CapWakeAlarm
+	CapBlockSuspend
+	CapAuditRead
+	CapPerfmon
+	CapBpf
+	CapCheckpointRestore
+)
+
+var capabilityNames = [...]string{
+	CapChown:             "cap_chown",
+	CapDacOverride:       "cap_dac_override",
+	CapDacReadSearch:     "cap_dac_read_search",
+	CapFowner:            "cap_fowner",
+	CapFsetid:            "cap_fsetid",
+	CapKill:              "cap_kill",
+	CapSetgid:            "cap_setgid",
+	CapSetuid:            "cap_setuid",
+	CapSetpcap:           "cap_setpcap",
+	CapLinuxImmutable:    "cap_linux_immutable",
+	CapNetBindService:    "cap_net_bind_service",
+	CapNetBroadcast:      "cap_net_broadcast",
+	CapNetAdmin:          "cap_net_admin",
+	CapNetRaw:            "cap_net_raw",
+	CapIpcLock:           "cap_ipc_lock",
+	CapIpcOwner:          "cap_ipc_owner",
+	CapSysModule:         "cap_sys_module",
+	CapSysRawio:          "cap_sys_rawio",
+	CapSysChroot:         "cap_sys_chroot",
+	CapSysPtrace:         "cap_sys_ptrace",
+	CapSysPacct:          "cap_sys_pacct",
+	CapSysAdmin:          "cap_sys_admin",
+	CapSysBoot:           "cap_sys_boot",
+	CapSysNice:           "cap_sys_nice",
+	CapSysResource:       "cap_sys_resource",
+	CapSysTime:           "cap_sys_time",
+	CapSysTtyConfig:      "cap_sys_tty_config",
+	CapMknod:             "cap_mknod",
+	CapLease:             "cap_lease",
+	CapAuditWrite:        "cap_audit_write",
+	CapAuditControl:      "cap_audit_control",
+	CapSetfcap:           "cap_setfcap",
+	CapMacOverride:       "cap_mac_override",
+	CapMacAdmin:          "cap_mac_admin",
+	CapSyslog:            "cap_syslog",
+	CapWakeAlarm:         "cap_wake_alarm",
+	CapBlockSuspend:      "cap_block_suspend",
+	CapAuditRead:         "cap_audit_read",
+	CapPerfmon:           "cap_perfmon",
+	CapBpf:               "cap_bpf",
+	CapCheckpointRestore: "cap_checkpoint_restore",
+}
+
+// String returns the name used by capsh(1) and getpcaps(8), e.g.
+// "cap_sys_admin", or "cap_<n>" for capabilities newer than this
+// package.
+func (c Capability) String() string {
+	if c >= 0 && int(c) < len(capabilityNames) {
+		return capabilityNames[c]
+	}
+	return "cap_" + strconv.Itoa(int(c))
+}
+
+// CapabilitySet is a capability bit mask as in /proc/<pid>/status.
+type CapabilitySet uint64
+
+func (s CapabilitySet) Has(c Capability) bool {
+	return c >= 0 && c < 64 && s&(1<<uint(c)) != 0
+}
+
+// List returns the capabilities in the set in ascending order.
+func (s CapabilitySet) List() []Capability {
+	list := make([]Capability, 0, bits.OnesCount64(uint64(s)))
+	for c := Capability(0); c < 64; c++ {
+		if s.Has(c) {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// String joins the capability names with commas.
+func (s CapabilitySet) String() string {
+	names := make([]string, 0, bits.OnesCount64(uint64(s)))
+	for _, c := range s.List() {
+		names = append(names, c.String())
+	}
+	return strings.Join(names, ",")
+}
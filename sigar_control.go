@@ -0,0 +1,151 @@
+package sigar
+
+import (
+	"errors"
+	"syscall"
+	"time"
+)
+
+// ErrProcWaitTimeout is returned by ProcControl.Wait when the process
+// is still running at the deadline.
+var ErrProcWaitTimeout = errors.New("gosigar: timed out waiting for process to exit")
+
+// procWaitInterval is how often ProcControl.Wait polls the process.
+const procWaitInterval = 10 * time.Millisecond
+
+// ProcControl acts on processes. Operations on a process which does
+// not exist return a NoSuchProcessError, refused ones a
+// PermissionError.
+type ProcControl struct{}
+
+// SignalTree sends sig to pid and then to its descendants in tree.
+// Each process is signalled through its ProcID as of when the tree was
+// built, so pids reused since are skipped like descendants which
+// exited meanwhile. Other errors are collected and joined.
+func (c ProcControl) SignalTree(tree *ProcTree, pid int, sig syscall.Signal) error {
+	if err := c.signalTreeID(tree, pid, sig); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, child := range tree.Descendants(pid) {
+		err := c.signalTreeID(tree, child, sig)
+		if err != nil && !errors.Is(err, syscall.ESRCH) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// signalTreeID signals pid by its ProcID in tree, or by its current
+// one if the tree does not know it.
+func (c ProcControl) signalTreeID(tree *ProcTree, pid int, sig syscall.Signal) error {
+	id, ok := tree.ID(pid)
+	if !ok {
+		if err := id.Get(pid); err != nil {
+			return err
+		}
+	}
+
+	return c.SignalID(&id, sig)
+}
+
+// Wait polls until pid terminated or timeout expired. Zombies count as
+// terminated, as they only wait to be reaped by their parent.
+func (c ProcControl) Wait(pid int, timeout time.Duration) error {
+	return procWait(timeout, func() (bool, error) {
+		err := c.Signal(pid, 0)
+		if errors.Is(err, syscall.ESRCH) {
+			return true, nil
+		}
+		var perr *PermissionError
+		if err != nil && !errors.As(err, &perr) {
+			return false, err
+		}
+
+		state := ProcState{}
+		err = state.Get(pid)
+		return errors.Is(err, syscall.ESRCH) || (err == nil && state.State == RunStateZombie), nil
+	})
+}
+
+// SignalID sends sig to the process identified by id. With an open
+// pidfd the signal can not reach another process, otherwise id is
+// checked right before signalling.
+func (c ProcControl) SignalID(id *ProcID, sig syscall.Signal) error {
+	if id.pidfd != nil {
+		return c.signalPidfd(id, sig)
+	}
+
+	if err := id.Check(); err != nil {
+		return err
+	}
+
+	return c.Signal(id.Pid, sig)
+}
+
+// ReniceID is Renice for the process identified by id, which is
+// checked right before.
+func (c ProcControl) ReniceID(id *ProcID, nice int) error {
+	if err := id.Check(); err != nil {
+		return err
+	}
+
+	return c.Renice(id.Pid, nice)
+}
+
+// SetAffinityID is SetAffinity for the process identified by id, which
+// is checked right before.
+func (c ProcControl) SetAffinityID(id *ProcID, cpus []int) error {
+	if err := id.Check(); err != nil {
+		return err
+	}
+
+	return c.SetAffinity(id.Pid, cpus)
+}
+
+// SetIOPriorityID is SetIOPriority for the process identified by id,
+// which is checked right before.
+func (c ProcControl) SetIOPriorityID(id *ProcID, class IOPrioClass, level int) error {
+	if err := id.Check(); err != nil {
+		return err
+	}
+
+	return c.SetIOPriority(id.Pid, class, level)
+}
+
+// WaitID is Wait for the process identified by id, which also counts
+// as terminated once its pid was reused.
+func (c ProcControl) WaitID(id *ProcID, timeout time.Duration) error {
+	return procWait(timeout, func() (bool, error) {
+		stat, err := id.check()
+		if errors.Is(err, syscall.ESRCH) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return stat.State == RunStateZombie, nil
+	})
+}
+
+// procWait polls exited until it reports true or timeout expired.
+func procWait(timeout time.Duration, exited func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		done, err := exited()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return ErrProcWaitTimeout
+		}
+		time.Sleep(procWaitInterval)
+	}
+}
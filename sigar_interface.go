@@ -2,11 +2,51 @@ package sigar
 
 import (
 	"errors"
+	"fmt"
+	"net"
+	"syscall"
 	"time"
 )
 
 var ErrNotImplemented = errors.New("gosigar: not implemented")
 
+// PermissionError is returned when a per-process file exists but may
+// not be read, typically because the process belongs to another user.
+// ProcControl operations which are refused set Op instead of Name.
+type PermissionError struct {
+	Pid  int
+	Name string
+	Op   string
+	Err  error
+}
+
+func (e *PermissionError) Error() string {
+	if e.Op != "" {
+		return fmt.Sprintf("gosigar: permission denied to %s pid %d", e.Op, e.Pid)
+	}
+	return fmt.Sprintf("gosigar: permission denied reading %s of pid %d", e.Name, e.Pid)
+}
+
+func (e *PermissionError) Unwrap() error {
+	return e.Err
+}
+
+// NoSuchProcessError is returned by ProcControl operations on a
+// process which does not exist (anymore). It matches syscall.ESRCH
+// like the errors of the getters.
+type NoSuchProcessError struct {
+	Pid int
+	Op  string
+}
+
+func (e *NoSuchProcessError) Error() string {
+	return fmt.Sprintf("gosigar: %s pid %d: no such process", e.Op, e.Pid)
+}
+
+func (e *NoSuchProcessError) Unwrap() error {
+	return syscall.ESRCH
+}
+
 type Sigar interface {
 	CollectCpuStats(collectionInterval time.Duration) (<-chan Cpu, chan<- struct{})
 	GetLoadAverage() (LoadAverage, error)
@@ -99,15 +139,55 @@ type ProcList struct {
 
 type RunState byte
 
+// Process states as reported in /proc/<pid>/stat, see proc(5)
 const (
-	RunStateSleep   = 'S'
-	RunStateRun     = 'R'
-	RunStateStop    = 'T'
-	RunStateZombie  = 'Z'
-	RunStateIdle    = 'D'
-	RunStateUnknown = '?'
+	RunStateSleep       = 'S'
+	RunStateRun         = 'R'
+	RunStateStop        = 'T'
+	RunStateZombie      = 'Z'
+	RunStateDiskSleep   = 'D'
+	RunStateTracingStop = 't'
+	RunStateDead        = 'X'
+	RunStateParked      = 'P'
+	RunStateTaskIdle    = 'I'
+	RunStateWaking      = 'W'
+	RunStateWakeKill    = 'K'
+	RunStateUnknown     = '?'
+
+	// Deprecated: 'D' is uninterruptible (disk) sleep, use
+	// RunStateDiskSleep, or RunStateTaskIdle for idle kernel threads.
+	RunStateIdle = RunStateDiskSleep
 )
 
+func (s RunState) String() string {
+	switch s {
+	case RunStateSleep:
+		return "sleeping"
+	case RunStateRun:
+		return "running"
+	case RunStateStop:
+		return "stopped"
+	case RunStateZombie:
+		return "zombie"
+	case RunStateDiskSleep:
+		return "disk sleep"
+	case RunStateTracingStop:
+		return "tracing stop"
+	case RunStateDead:
+		return "dead"
+	case RunStateParked:
+		return "parked"
+	case RunStateTaskIdle:
+		return "idle"
+	case RunStateWaking:
+		return "waking"
+	case RunStateWakeKill:
+		return "wakekill"
+	default:
+		return "unknown"
+	}
+}
+
 type ProcState struct {
 	Name      string
 	State     RunState
@@ -118,6 +198,217 @@ type ProcState struct {
 	Processor int
 }
 
+// ProcFlagKernelThread marks kernel threads in ProcStat.Flags
+// (PF_KTHREAD in linux/sched.h).
+const ProcFlagKernelThread = 0x00200000
+
+// ProcStat holds the fields of /proc/<pid>/stat, see proc(5). Times
+// are in clock ticks, Rss is in pages and Vsize is in bytes.
+type ProcStat struct {
+	Pid         int
+	Name        string
+	State       RunState
+	Ppid        int
+	Pgrp        int
+	Session     int
+	Tty         int
+	Tpgid       int
+	Flags       uint64
+	MinFlt      uint64
+	CMinFlt     uint64
+	MajFlt      uint64
+	CMajFlt     uint64
+	Utime       uint64
+	Stime       uint64
+	Cutime      int64
+	Cstime      int64
+	Priority    int
+	Nice        int
+	NumThreads  int
+	ItRealValue int64
+	StartTime   uint64
+	Vsize       uint64
+	Rss         uint64
+	RssLim      uint64
+	StartCode   uint64
+	EndCode     uint64
+	StartStack  uint64
+	KstkEsp     uint64
+	KstkEip     uint64
+	Signal      uint64
+	Blocked     uint64
+	SigIgnore   uint64
+	SigCatch    uint64
+	Wchan       uint64
+	Nswap       uint64
+	CNswap      uint64
+	ExitSignal  int
+	Processor   int
+	RtPriority  uint64
+	Policy      uint64
+
+	DelayAcctBlkioTicks uint64
+	GuestTime           uint64
+	CGuestTime          int64
+
+	StartData uint64
+	EndData   uint64
+	StartBrk  uint64
+	ArgStart  uint64
+	ArgEnd    uint64
+	EnvStart  uint64
+	EnvEnd    uint64
+	ExitCode  int
+}
+
+// IsKernelThread reports whether the process is a kernel thread,
+// i.e. flagged PF_KTHREAD. Without flags, e.g. from the short stat
+// format, it falls back to kthreadd (pid 2) and its children, which
+// is wrong inside a pid namespace where pid 2 is an ordinary process.
+func (s *ProcStat) IsKernelThread() bool {
+	if s.Flags != 0 {
+		return s.Flags&ProcFlagKernelThread != 0
+	}
+	return s.Pid == 2 || s.Ppid == 2
+}
+
+// ProcTty describes the session, process groups and controlling
+// terminal of a process. Nr is the raw tty_nr, 0 without terminal.
+// Name is the device below /dev, e.g. "pts/3" or "ttyS0", and empty
+// if there is no terminal or it could not be resolved. Tpgid is the
+// foreground process group of the terminal, -1 without terminal.
+type ProcTty struct {
+	Nr      int
+	Major   uint32
+	Minor   uint32
+	Name    string
+	Session int
+	Pgrp    int
+	Tpgid   int
+}
+
+// IsForeground reports whether the process is in the foreground
+// process group of its terminal.
+func (t *ProcTty) IsForeground() bool {
+	return t.Nr != 0 && t.Tpgid == t.Pgrp
+}
+
+// ProcStatus holds the fields of /proc/<pid>/status. Memory sizes
+// are in bytes, signal masks are bit sets indexed by signal - 1.
+type ProcStatus struct {
+	Name      string
+	State     RunState
+	Tgid      int
+	Pid       int
+	Ppid      int
+	TracerPid int
+
+	// Real, effective, saved set and filesystem ids
+	Uid    int
+	Euid   int
+	Suid   int
+	FsUid  int
+	Gid    int
+	Egid   int
+	Sgid   int
+	FsGid  int
+	Groups []int
+
+	// Ids in the nested pid namespaces, outermost first
+	NStgid []int
+	NSpid  []int
+
+	FDSize  int
+	Threads int
+
+	VmPeak   uint64
+	VmSize   uint64
+	VmLck    uint64
+	VmPin    uint64
+	VmHWM    uint64
+	VmRSS    uint64
+	RssAnon  uint64
+	RssFile  uint64
+	RssShmem uint64
+	VmData   uint64
+	VmStk    uint64
+	VmExe    uint64
+	VmLib    uint64
+	VmPTE    uint64
+	VmSwap   uint64
+
+	SigPnd uint64
+	ShdPnd uint64
+	SigBlk uint64
+	SigIgn uint64
+	SigCgt uint64
+
+	CapInh uint64
+	CapPrm uint64
+	CapEff uint64
+	CapBnd uint64
+	CapAmb uint64
+
+	NoNewPrivs     bool
+	Seccomp        int
+	SeccompFilters int
+
+	CpusAllowedList []int
+	MemsAllowedList []int
+
+	VoluntaryCtxtSwitches    uint64
+	NonvoluntaryCtxtSwitches uint64
+}
+
+// SeccompMode is the seccomp(2) mode of a process.
+type SeccompMode int
+
+const (
+	SeccompDisabled SeccompMode = iota
+	SeccompStrict
+	SeccompFilter
+)
+
+func (m SeccompMode) String() string {
+	switch m {
+	case SeccompDisabled:
+		return "disabled"
+	case SeccompStrict:
+		return "strict"
+	case SeccompFilter:
+		return "filter"
+	default:
+		return "unknown"
+	}
+}
+
+// ProcSecurity is the security context of a process: its capability
+// sets, no_new_privs and seccomp state and the label of the active
+// LSM, e.g. an AppArmor profile or SELinux context. Label is empty if
+// no LSM is active.
+type ProcSecurity struct {
+	Inheritable    CapabilitySet
+	Permitted      CapabilitySet
+	Effective      CapabilitySet
+	Bounding       CapabilitySet
+	Ambient        CapabilitySet
+	NoNewPrivs     bool
+	Seccomp        SeccompMode
+	SeccompFilters int
+	Label          string
+}
+
+// ProcCred identifies the owner of a process. User and Group name
+// the effective ids, falling back to the numeric id if unknown.
+type ProcCred struct {
+	Uid   int
+	Gid   int
+	Euid  int
+	Egid  int
+	User  string
+	Group string
+}
+
 type ProcMem struct {
 	Size        uint64
 	Resident    uint64
@@ -127,6 +418,50 @@ type ProcMem struct {
 	PageFaults  uint64
 }
 
+// ProcMemDetail breaks down the memory of a process into proportional
+// (Pss) and unique (Uss) set sizes, in bytes. Unlike the resident set
+// size these can be summed over processes sharing pages.
+type ProcMemDetail struct {
+	Rss           uint64
+	Pss           uint64
+	PssAnon       uint64
+	PssFile       uint64
+	PssShmem      uint64
+	SharedClean   uint64
+	SharedDirty   uint64
+	PrivateClean  uint64
+	PrivateDirty  uint64
+	Uss           uint64
+	Swap          uint64
+	SwapPss       uint64
+	AnonHugePages uint64
+	Locked        uint64
+}
+
+// ProcMap is a memory mapping of a process. Rss, Pss and Swap are
+// only set by ProcMapList.GetWithUsage.
+type ProcMap struct {
+	Start  uint64
+	End    uint64
+	Perms  string
+	Offset uint64
+	Dev    string
+	Inode  uint64
+	Path   string
+
+	Rss  uint64
+	Pss  uint64
+	Swap uint64
+}
+
+func (m *ProcMap) Size() uint64 {
+	return m.End - m.Start
+}
+
+type ProcMapList struct {
+	List []ProcMap
+}
+
 type ProcTime struct {
 	StartTime uint64
 	User      uint64
@@ -141,12 +476,503 @@ type ProcCpu struct {
 	cache    map[int]ProcCpu
 }
 
+type ProcIO struct {
+	Rchar               uint64
+	Wchar               uint64
+	Syscr               uint64
+	Syscw               uint64
+	ReadBytes           uint64
+	WriteBytes          uint64
+	CancelledWriteBytes uint64
+}
+
+// ProcIORate holds the I/O of a process between two samples.
+type ProcIORate struct {
+	Pid        int
+	Interval   time.Duration
+	ReadBytes  uint64
+	WriteBytes uint64
+	Rchar      uint64
+	Wchar      uint64
+
+	ReadBytesPerSec  float64
+	WriteBytesPerSec float64
+}
+
+type ProcThread struct {
+	Tid int
+	ProcState
+	ProcTime
+
+	VoluntaryCtxtSwitches    uint64
+	NonvoluntaryCtxtSwitches uint64
+}
+
+type ProcThreadList struct {
+	List []ProcThread
+}
+
+// ProcThreadCpu is a thread together with its CPU usage since the
+// previous sample, 1.0 being one fully used CPU.
+type ProcThreadCpu struct {
+	ProcThread
+	Percent float64
+}
+
 type ProcArgs struct {
 	List []string
 }
 
+type ProcEnv struct {
+	// Vars maps names to values, List keeps the raw `NAME=value`
+	// entries in their original order.
+	Vars map[string]string
+	List []string
+}
+
+// ProcAppTags attributes a process to a Cloud Foundry application
+// instance, see ProcEnv.AppTags.
+type ProcAppTags struct {
+	AppGuid       string
+	AppName       string
+	SpaceGuid     string
+	SpaceName     string
+	OrgGuid       string
+	OrgName       string
+	InstanceGuid  string
+	InstanceIndex string
+}
+
+type ProcFdType int
+
+const (
+	ProcFdUnknown ProcFdType = iota
+	ProcFdFile
+	ProcFdDir
+	ProcFdSocket
+	ProcFdPipe
+	ProcFdAnonInode
+	ProcFdDevice
+)
+
+func (t ProcFdType) String() string {
+	switch t {
+	case ProcFdFile:
+		return "REG"
+	case ProcFdDir:
+		return "DIR"
+	case ProcFdSocket:
+		return "SOCK"
+	case ProcFdPipe:
+		return "FIFO"
+	case ProcFdAnonInode:
+		return "a_inode"
+	case ProcFdDevice:
+		return "CHR"
+	default:
+		return "unknown"
+	}
+}
+
+// ProcFd is an open file descriptor. Inode is set for sockets and
+// pipes, AnonInode names the kind of anonymous inode, e.g. eventfd,
+// eventpoll, inotify or timerfd. Flags are the open(2) flags.
+type ProcFd struct {
+	Fd        int
+	Type      ProcFdType
+	Target    string
+	Inode     uint64
+	AnonInode string
+	Pos       uint64
+	Flags     uint64
+}
+
+type ProcFdList struct {
+	List []ProcFd
+}
+
+// RlimitInfinity represents an unlimited resource limit.
+const RlimitInfinity = ^uint64(0)
+
+// ProcFdCount is the number of open file descriptors of a process
+// along with its RLIMIT_NOFILE limits.
+type ProcFdCount struct {
+	Count     uint64
+	SoftLimit uint64
+	HardLimit uint64
+}
+
+// Usage returns Count as a fraction of the soft limit.
+func (c *ProcFdCount) Usage() float64 {
+	if c.SoftLimit == 0 || c.SoftLimit == RlimitInfinity {
+		return 0
+	}
+	return float64(c.Count) / float64(c.SoftLimit)
+}
+
+type Rlimit struct {
+	Soft uint64
+	Hard uint64
+}
+
+func (r Rlimit) SoftUnlimited() bool {
+	return r.Soft == RlimitInfinity
+}
+
+func (r Rlimit) HardUnlimited() bool {
+	return r.Hard == RlimitInfinity
+}
+
+// ProcLimits holds the resource limits of a process, see
+// getrlimit(2). Unlimited values are RlimitInfinity.
+type ProcLimits struct {
+	Cpu            Rlimit
+	FileSize       Rlimit
+	Data           Rlimit
+	Stack          Rlimit
+	Core           Rlimit
+	Rss            Rlimit
+	Processes      Rlimit
+	OpenFiles      Rlimit
+	LockedMemory   Rlimit
+	AddressSpace   Rlimit
+	FileLocks      Rlimit
+	PendingSignals Rlimit
+	MsgQueue       Rlimit
+	Nice           Rlimit
+	RtPriority     Rlimit
+	RtTimeout      Rlimit
+}
+
+// ProcLimitUsage compares a live value with its limit.
+type ProcLimitUsage struct {
+	Used  uint64
+	Limit Rlimit
+}
+
+// Usage returns Used as a fraction of the soft limit, 0 if unlimited.
+func (u ProcLimitUsage) Usage() float64 {
+	if u.Limit.Soft == 0 || u.Limit.SoftUnlimited() {
+		return 0
+	}
+	return float64(u.Used) / float64(u.Limit.Soft)
+}
+
+// ProcLimitsUsage relates the live usage of a process to the limits
+// most commonly hit: open files to NOFILE, threads to NPROC and the
+// virtual memory size to AS. Note that NPROC is accounted per user,
+// the thread count of a single process is a lower bound.
+type ProcLimitsUsage struct {
+	OpenFiles    ProcLimitUsage
+	Threads      ProcLimitUsage
+	AddressSpace ProcLimitUsage
+}
+
+// ProcCgroup is a line of /proc/<pid>/cgroup. Controllers is empty
+// for the cgroup v2 unified hierarchy.
+type ProcCgroup struct {
+	HierarchyId int
+	Controllers []string
+	Path        string
+}
+
+type ProcCgroups struct {
+	List []ProcCgroup
+}
+
+// Controller returns the path of the process in the cgroup v1
+// hierarchy the named controller, e.g. "memory", is attached to.
+func (pc *ProcCgroups) Controller(name string) (string, bool) {
+	for _, cg := range pc.List {
+		for _, controller := range cg.Controllers {
+			if controller == name {
+				return cg.Path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Unified returns the path of the process in the cgroup v2 hierarchy.
+func (pc *ProcCgroups) Unified() (string, bool) {
+	for _, cg := range pc.List {
+		if cg.HierarchyId == 0 && len(cg.Controllers) == 0 {
+			return cg.Path, true
+		}
+	}
+	return "", false
+}
+
+// ProcNamespaces holds the inode numbers identifying the namespaces of
+// a process. Processes share a namespace if the inodes are equal, 0
+// means the namespace type is not supported by the kernel.
+type ProcNamespaces struct {
+	Mnt    uint64
+	Net    uint64
+	Pid    uint64
+	Uts    uint64
+	Ipc    uint64
+	User   uint64
+	Cgroup uint64
+	Time   uint64
+}
+
+// IOPrioClass is the I/O scheduling class of ioprio_set(2). The
+// levels 0 (highest) to 7 apply to RealTime and BestEffort.
+type IOPrioClass int
+
+const (
+	IOPrioClassNone IOPrioClass = iota
+	IOPrioClassRealTime
+	IOPrioClassBestEffort
+	IOPrioClassIdle
+)
+
+func (c IOPrioClass) String() string {
+	switch c {
+	case IOPrioClassRealTime:
+		return "realtime"
+	case IOPrioClassBestEffort:
+		return "best-effort"
+	case IOPrioClassIdle:
+		return "idle"
+	default:
+		return "none"
+	}
+}
+
+// SchedPolicy is the scheduling policy of a process, see sched(7).
+type SchedPolicy int
+
+const (
+	SchedOther    SchedPolicy = 0
+	SchedFifo     SchedPolicy = 1
+	SchedRR       SchedPolicy = 2
+	SchedBatch    SchedPolicy = 3
+	SchedIdle     SchedPolicy = 5
+	SchedDeadline SchedPolicy = 6
+)
+
+func (p SchedPolicy) String() string {
+	switch p {
+	case SchedOther:
+		return "other"
+	case SchedFifo:
+		return "fifo"
+	case SchedRR:
+		return "rr"
+	case SchedBatch:
+		return "batch"
+	case SchedIdle:
+		return "idle"
+	case SchedDeadline:
+		return "deadline"
+	default:
+		return "unknown"
+	}
+}
+
+// ProcSched describes how a process is scheduled. With
+// IOPrioClassNone the kernel derives the I/O priority from Nice.
+// RunTime and WaitTime, the time spent on a cpu and waiting on a run
+// queue, are zero if the kernel lacks schedstats.
+type ProcSched struct {
+	Affinity    []int
+	Policy      SchedPolicy
+	RtPriority  int
+	Nice        int
+	IOPrioClass IOPrioClass
+	IOPrioLevel int
+	RunTime     time.Duration
+	WaitTime    time.Duration
+	Timeslices  uint64
+}
+
 type ProcExe struct {
 	Name string
 	Cwd  string
 	Root string
 }
+
+type NetIface struct {
+	Name string
+
+	RxBytes      uint64
+	RxPackets    uint64
+	RxErrors     uint64
+	RxDropped    uint64
+	RxFifo       uint64
+	RxFrame      uint64
+	RxCompressed uint64
+	RxMulticast  uint64
+
+	TxBytes      uint64
+	TxPackets    uint64
+	TxErrors     uint64
+	TxDropped    uint64
+	TxFifo       uint64
+	TxCollisions uint64
+	TxCarrier    uint64
+	TxCompressed uint64
+}
+
+type NetIfaceList struct {
+	List []NetIface
+}
+
+// NetNamespace holds the network usage of all processes sharing a
+// network namespace, e.g. the processes of one container.
+type NetNamespace struct {
+	Inode    uint64
+	Pids     []int
+	Ifaces   NetIfaceList
+	SockStat SockStat
+}
+
+type NetNamespaceList struct {
+	List []NetNamespace
+}
+
+type SockMemLimits struct {
+	Min      uint64
+	Pressure uint64
+	Max      uint64
+}
+
+type SockStat struct {
+	SocketsUsed uint64
+
+	TcpInUse    uint64
+	TcpOrphan   uint64
+	TcpTimeWait uint64
+	TcpAlloc    uint64
+	TcpMem      uint64
+	Tcp6InUse   uint64
+
+	UdpInUse  uint64
+	UdpMem    uint64
+	Udp6InUse uint64
+
+	FragInUse   uint64
+	FragMemory  uint64
+	Frag6InUse  uint64
+	Frag6Memory uint64
+
+	// Kernel thresholds, in pages, as found in tcp_mem and udp_mem
+	TcpMemLimits SockMemLimits
+	UdpMemLimits SockMemLimits
+}
+
+// TcpMemPressure returns TCP memory usage as a fraction of the
+// pressure threshold. Values >= 1 mean the kernel has started
+// moderating socket buffers.
+func (s *SockStat) TcpMemPressure() float64 {
+	if s.TcpMemLimits.Pressure == 0 {
+		return 0
+	}
+	return float64(s.TcpMem) / float64(s.TcpMemLimits.Pressure)
+}
+
+// UdpMemPressure returns UDP memory usage as a fraction of the
+// pressure threshold.
+func (s *SockStat) UdpMemPressure() float64 {
+	if s.UdpMemLimits.Pressure == 0 {
+		return 0
+	}
+	return float64(s.UdpMem) / float64(s.UdpMemLimits.Pressure)
+}
+
+// Route flags, see RTF_* in linux/route.h
+const (
+	RouteFlagUp      = 0x0001
+	RouteFlagGateway = 0x0002
+	RouteFlagHost    = 0x0004
+	RouteFlagReject  = 0x0200
+)
+
+type NetRoute struct {
+	Destination net.IP
+	Gateway     net.IP
+	Mask        net.IPMask
+	Iface       string
+	Metric      uint32
+	Flags       uint32
+}
+
+// IsDefault reports whether the route is an active default route.
+func (r *NetRoute) IsDefault() bool {
+	ones, _ := r.Mask.Size()
+	return ones == 0 && r.Destination.IsUnspecified() &&
+		r.Flags&RouteFlagUp != 0 && r.Flags&RouteFlagReject == 0
+}
+
+type NetRouteList struct {
+	List []NetRoute
+}
+
+// DefaultRoute returns the default route with the lowest metric,
+// preferring IPv4 over IPv6.
+func (rl *NetRouteList) DefaultRoute() (NetRoute, bool) {
+	var best NetRoute
+	found := false
+
+	for _, route := range rl.List {
+		if !route.IsDefault() {
+			continue
+		}
+		if !found {
+			best, found = route, true
+			continue
+		}
+		bestIsV4 := best.Destination.To4() != nil
+		routeIsV4 := route.Destination.To4() != nil
+		if routeIsV4 != bestIsV4 {
+			if routeIsV4 {
+				best = route
+			}
+			continue
+		}
+		if route.Metric < best.Metric {
+			best = route
+		}
+	}
+
+	return best, found
+}
+
+// DefaultIface returns the interface of the default route.
+func (rl *NetRouteList) DefaultIface() (string, bool) {
+	route, found := rl.DefaultRoute()
+	return route.Iface, found
+}
+
+type NeighborState int
+
+const (
+	NeighborIncomplete NeighborState = iota
+	NeighborReachable
+	NeighborPermanent
+)
+
+func (s NeighborState) String() string {
+	switch s {
+	case NeighborReachable:
+		return "reachable"
+	case NeighborPermanent:
+		return "permanent"
+	default:
+		return "incomplete"
+	}
+}
+
+type NetNeighbor struct {
+	IP           net.IP
+	HardwareAddr net.HardwareAddr
+	Iface        string
+	Flags        uint32
+	State        NeighborState
+}
+
+type NetNeighborList struct {
+	List []NetNeighbor
+}
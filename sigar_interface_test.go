@@ -1,10 +1,15 @@
 package sigar
 
 import (
+	"context"
 	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"syscall"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -184,4 +189,347 @@ var _ = Describe("Sigar", func() {
 
 		Expect([]string{"go", "ginkgo"}).To(ContainElement(filepath.Base(exe.Name)))
 	})
+
+	It("sock stat", func() {
+		sockStat := SockStat{}
+		err := sockStat.Get()
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(sockStat.SocketsUsed).To(BeNumerically(">", 0))
+	})
+
+	It("net iface list", func() {
+		ifaces := NetIfaceList{}
+		err := ifaces.Get()
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(len(ifaces.List)).To(BeNumerically(">", 0))
+	})
+
+	It("proc stat", func() {
+		stat := ProcStat{}
+		err := stat.Get(os.Getppid())
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(stat.Pid).To(Equal(os.Getppid()))
+		Expect(stat.NumThreads).To(BeNumerically(">=", 1))
+
+		err = stat.Get(invalidPid)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("proc status", func() {
+		status := ProcStatus{}
+		err := status.Get(os.Getpid())
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(status.Pid).To(Equal(os.Getpid()))
+		Expect(status.Euid).To(Equal(os.Geteuid()))
+		Expect(status.Threads).To(BeNumerically(">=", 1))
+
+		err = status.Get(invalidPid)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("proc thread list", func() {
+		threads := ProcThreadList{}
+		err := threads.Get(os.Getpid())
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(len(threads.List)).To(BeNumerically(">=", 1))
+
+		err = threads.Get(invalidPid)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("proc env", func() {
+		env := ProcEnv{}
+		err := env.Get(os.Getpid())
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(len(env.List)).To(BeNumerically(">=", len(env.Vars)))
+	})
+
+	It("proc mem detail", func() {
+		detail := ProcMemDetail{}
+		err := detail.Get(os.Getpid())
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(detail.Pss).To(BeNumerically(">", 0))
+		Expect(detail.Pss).To(BeNumerically("<=", detail.Rss))
+		Expect(detail.Uss).To(BeNumerically("<=", detail.Pss))
+	})
+
+	It("proc fd list", func() {
+		fds := ProcFdList{}
+		err := fds.Get(os.Getpid())
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		count := ProcFdCount{}
+		Expect(count.Get(os.Getpid())).To(Succeed())
+		Expect(count.Count).To(BeNumerically(">=", 3))
+		Expect(count.SoftLimit).To(BeNumerically(">=", count.Count))
+	})
+
+	It("proc limits", func() {
+		limits := ProcLimits{}
+		err := limits.Get(os.Getpid())
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(limits.OpenFiles.Soft).To(BeNumerically(">", 0))
+		Expect(limits.OpenFiles.Soft).To(BeNumerically("<=", limits.OpenFiles.Hard))
+	})
+
+	It("proc namespaces", func() {
+		namespaces := ProcNamespaces{}
+		err := namespaces.Get(os.Getpid())
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		parent := ProcNamespaces{}
+		Expect(parent.Get(os.Getppid())).To(Succeed())
+		Expect(namespaces.Net).To(Equal(parent.Net))
+	})
+
+	It("proc tree", func() {
+		tree := ProcTree{}
+		err := tree.Get()
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(tree.Children(os.Getppid())).To(ContainElement(os.Getpid()))
+		Expect(tree.Ancestors(os.Getpid())).To(HaveLen(tree.Depth(os.Getpid())))
+
+		usage, err := tree.Usage(os.Getpid())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(usage.Procs).To(BeNumerically(">=", 1))
+		Expect(usage.Rss).To(BeNumerically(">", 0))
+	})
+
+	It("proc query", func() {
+		state := ProcState{}
+		err := state.Get(os.Getpid())
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		q := ProcQuery{}
+		q.Name(regexp.MustCompile("^" + regexp.QuoteMeta(state.Name) + "$")).Ppid(os.Getppid())
+		pids, err := q.Find()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pids).To(ContainElement(os.Getpid()))
+	})
+
+	It("proc control", func() {
+		control := ProcControl{}
+		err := control.Signal(os.Getpid(), 0)
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		err = control.Signal(invalidPid, syscall.SIGTERM)
+		var nerr *NoSuchProcessError
+		Expect(errors.As(err, &nerr)).To(BeTrue())
+		Expect(nerr.Pid).To(Equal(invalidPid))
+		Expect(err).To(MatchError(syscall.ESRCH))
+
+		Expect(control.Wait(os.Getpid(), 20*time.Millisecond)).To(MatchError(ErrProcWaitTimeout))
+
+		cmd := exec.Command("sh", "-c", "sleep 30 & wait")
+		Expect(cmd.Start()).To(Succeed())
+		pid := cmd.Process.Pid
+		defer cmd.Wait() //nolint:errcheck
+
+		tree := ProcTree{}
+		Eventually(func() []int {
+			Expect(tree.Get()).To(Succeed())
+			return tree.Descendants(pid)
+		}).ShouldNot(BeEmpty())
+
+		Expect(control.Renice(pid, 5)).To(Succeed())
+		state := ProcState{}
+		Expect(state.Get(pid)).To(Succeed())
+		Expect(state.Nice).To(Equal(5))
+
+		status := ProcStatus{}
+		Expect(status.Get(os.Getpid())).To(Succeed())
+		cpu := status.CpusAllowedList[0]
+		Expect(control.SetAffinity(pid, []int{cpu})).To(Succeed())
+		Expect(status.Get(pid)).To(Succeed())
+		Expect(status.CpusAllowedList).To(Equal([]int{cpu}))
+
+		Expect(control.SetIOPriority(pid, IOPrioClassBestEffort, 7)).To(Succeed())
+		sched := ProcSched{}
+		Expect(sched.Get(pid)).To(Succeed())
+		Expect(sched.IOPrioClass).To(Equal(IOPrioClassBestEffort))
+		Expect(sched.IOPrioLevel).To(Equal(7))
+		Expect(sched.Affinity).To(Equal([]int{cpu}))
+
+		Expect(control.SignalTree(&tree, pid, syscall.SIGTERM)).To(Succeed())
+		for _, p := range append([]int{pid}, tree.Descendants(pid)...) {
+			Expect(control.Wait(p, 5*time.Second)).To(Succeed())
+		}
+	})
+
+	It("proc sched", func() {
+		sched := ProcSched{}
+		err := sched.Get(os.Getpid())
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		status := ProcStatus{}
+		Expect(status.Get(os.Getpid())).To(Succeed())
+		Expect(sched.Affinity).To(Equal(status.CpusAllowedList))
+		Expect(sched.Policy).To(Equal(SchedOther))
+	})
+
+	It("proc cpu sampler", func() {
+		sampler := ProcCpuSampler{}
+		_, ok, err := sampler.Sample(os.Getpid())
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeFalse())
+
+		_, ok, err = sampler.Sample(os.Getpid())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+
+		_, _, err = sampler.Sample(invalidPid)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("top processes", func() {
+		top := TopProcesses{Metric: TopByRss, N: 3}
+		err := top.Get()
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+		Expect(top.List).ToNot(BeEmpty())
+		Expect(len(top.List)).To(BeNumerically("<=", 3))
+		Expect(top.List[0].Value).To(BeNumerically(">", 0))
+	})
+
+	It("proc snapshot", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		snapshot := ProcSnapshot{Fields: SnapshotAll}
+		err := snapshot.Get(ctx)
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		var self *ProcSnapshotEntry
+		for i := range snapshot.List {
+			if snapshot.List[i].Pid == os.Getpid() {
+				self = &snapshot.List[i]
+			}
+		}
+		Expect(self).ToNot(BeNil())
+		Expect(self.Err).ToNot(HaveOccurred())
+		Expect(self.Args.List).ToNot(BeEmpty())
+		Expect(self.Fds.Count).To(BeNumerically(">=", 3))
+	})
+
+	It("proc id", func() {
+		id := ProcID{}
+		err := id.Get(os.Getpid())
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+		Expect(id.Check()).To(Succeed())
+
+		cmd := exec.Command("sleep", "30")
+		Expect(cmd.Start()).To(Succeed())
+		defer cmd.Wait() //nolint:errcheck
+
+		child := ProcID{}
+		Expect(child.Get(cmd.Process.Pid)).To(Succeed())
+		defer child.Close() //nolint:errcheck
+
+		err = child.OpenPidfd()
+		if errors.Is(err, syscall.ENOSYS) {
+			Skip("pidfd not supported by this kernel")
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		control := ProcControl{}
+		Expect(control.SignalID(&child, 0)).To(Succeed())
+		Expect(control.SignalID(&child, syscall.SIGKILL)).To(Succeed())
+		Expect(control.WaitID(&child, 5*time.Second)).To(Succeed())
+		Expect(child.Close()).To(Succeed())
+
+		stale := ProcID{Pid: os.Getpid(), StartTime: id.StartTime + 1}
+		Expect(control.SignalID(&stale, syscall.SIGKILL)).To(MatchError(syscall.ESRCH))
+	})
+
+	It("proc tty", func() {
+		tty := ProcTty{}
+		err := tty.Get(os.Getpid())
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tty.Session).To(BeNumerically(">", 0))
+		Expect(tty.Pgrp).To(BeNumerically(">", 0))
+		if tty.Nr == 0 {
+			Expect(tty.Name).To(BeEmpty())
+		}
+	})
+
+	It("proc security", func() {
+		security := ProcSecurity{}
+		err := security.Get(os.Getpid())
+		if errors.Is(err, ErrNotImplemented) {
+			Skip("Not implemented on " + runtime.GOOS)
+		}
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(security.Bounding).ToNot(BeZero())
+		Expect(uint64(security.Effective) &^ uint64(security.Permitted)).To(BeZero())
+
+		status := ProcStatus{}
+		Expect(status.Get(os.Getpid())).To(Succeed())
+		Expect(security.Effective).To(Equal(CapabilitySet(status.CapEff)))
+	})
 })
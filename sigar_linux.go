@@ -3,12 +3,20 @@ package sigar
 import (
 	"bufio"
 	"bytes"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"io"
+	"net"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
 )
 
 const (
@@ -26,14 +34,35 @@ var Procd string
 var Etcd string
 var Sysd1 string
 var Sysd2 string
+var Devd string
+
+// Name tables for ProcCred, reloaded when the files change
+var userNames, groupNames idNameTable
+
+// Terminal drivers for ProcTty
+var ttyDrivers ttyDriverTable
 
 // Files in system directories used here
+//   - Etcd
+//       - /mtab
+//       - /passwd, /group
 //   - Procd
 //       - /stat
 //       - /meminfo
 //       - /self/cgroup | 'grep :memory:' | split ':' | last => cgroup
 //       - /self/cgroup | 'grep ::'       | split ':' | last => cgroup/fallback
 //       - /self/mounts
+//       - /net/sockstat, /net/sockstat6
+//       - /sys/net/ipv4/tcp_mem, /sys/net/ipv4/udp_mem
+//       - /net/route, /net/ipv6_route
+//       - /net/dev
+//       - /<pid>/net/dev, /<pid>/net/sockstat
+//       - /<pid>/ns/*
+//       - /<pid>/cgroup
+//       - /net/arp
+//       - /tty/drivers
+//   - Devd
+//       - terminal devices, e.g. /pts/<n>, /tty<n>
 //   - Sysd1 (cgroup v1)
 //       - memory/<cgroup>/memory.limit_in_bytes
 //       - memory/<cgroup>/memory.stat
@@ -53,6 +82,7 @@ func init() {
 
 	Procd = "/proc"
 	Etcd = "/etc"
+	Devd = "/dev"
 	Sysd1 = ""
 	Sysd2 = ""
 
@@ -282,6 +312,260 @@ func (fsl *FileSystemList) Get() error { //nolint:staticcheck
 	return err
 }
 
+func (s *SockStat) Get() error { //nolint:staticcheck
+	return s.get(Procd + "/net")
+}
+
+// GetForPid reads the socket summary of the network namespace pid
+// belongs to.
+func (s *SockStat) GetForPid(pid int) error { //nolint:staticcheck
+	return procNotFound(s.get(procFileName(pid, "net")))
+}
+
+func (s *SockStat) get(netDir string) error {
+	// Optional files missing now must not leave values of a reuse
+	*s = SockStat{}
+
+	table := map[string]*uint64{
+		"sockets:used": &s.SocketsUsed,
+		"TCP:inuse":    &s.TcpInUse,
+		"TCP:orphan":   &s.TcpOrphan,
+		"TCP:tw":       &s.TcpTimeWait,
+		"TCP:alloc":    &s.TcpAlloc,
+		"TCP:mem":      &s.TcpMem,
+		"UDP:inuse":    &s.UdpInUse,
+		"UDP:mem":      &s.UdpMem,
+		"FRAG:inuse":   &s.FragInUse,
+		"FRAG:memory":  &s.FragMemory,
+		"TCP6:inuse":   &s.Tcp6InUse,
+		"UDP6:inuse":   &s.Udp6InUse,
+		"FRAG6:inuse":  &s.Frag6InUse,
+		"FRAG6:memory": &s.Frag6Memory,
+	}
+
+	if err := parseSockStat(netDir+"/sockstat", table); err != nil {
+		return err
+	}
+
+	// sockstat6 is absent when IPv6 is disabled
+	parseSockStat(netDir+"/sockstat6", table) //nolint:errcheck
+
+	// The thresholds are optional, e.g. not visible in some containers
+	parseSockMemLimits(Procd+"/sys/net/ipv4/tcp_mem", &s.TcpMemLimits) //nolint:errcheck
+	parseSockMemLimits(Procd+"/sys/net/ipv4/udp_mem", &s.UdpMemLimits) //nolint:errcheck
+
+	return nil
+}
+
+func (il *NetIfaceList) Get() error { //nolint:staticcheck
+	return il.get(Procd + "/net/dev")
+}
+
+// GetForPid reads the interface counters of the network namespace
+// pid belongs to.
+func (il *NetIfaceList) GetForPid(pid int) error { //nolint:staticcheck
+	return procNotFound(il.get(procFileName(pid, "net/dev")))
+}
+
+func (il *NetIfaceList) get(file string) error {
+	capacity := len(il.List)
+	if capacity == 0 {
+		capacity = 4
+	}
+	list := make([]NetIface, 0, capacity)
+
+	// The first two lines are headers, entries have the form
+	// `name: rx_bytes rx_packets ... tx_compressed`
+	err := readFile(file, func(line string) bool {
+		name, counters, found := strings.Cut(line, ":")
+		if !found {
+			return true
+		}
+
+		fields := strings.Fields(counters)
+		if len(fields) < 16 {
+			return true
+		}
+
+		iface := NetIface{Name: strings.TrimSpace(name)}
+		values := []*uint64{
+			&iface.RxBytes, &iface.RxPackets, &iface.RxErrors, &iface.RxDropped,
+			&iface.RxFifo, &iface.RxFrame, &iface.RxCompressed, &iface.RxMulticast,
+			&iface.TxBytes, &iface.TxPackets, &iface.TxErrors, &iface.TxDropped,
+			&iface.TxFifo, &iface.TxCollisions, &iface.TxCarrier, &iface.TxCompressed,
+		}
+		for i, ptr := range values {
+			*ptr, _ = strtoull(fields[i]) //nolint:errcheck
+		}
+
+		list = append(list, iface)
+
+		return true
+	})
+
+	il.List = list
+
+	return err
+}
+
+func (nl *NetNamespaceList) Get() error { //nolint:staticcheck
+	pids := ProcList{}
+	if err := pids.Get(); err != nil {
+		return err
+	}
+
+	return nl.GetForPids(pids.List)
+}
+
+// GetForPids groups pids by network namespace and reads the
+// counters of every namespace once. Processes whose namespace cannot
+// be determined, e.g. due to missing permissions, are skipped, as are
+// namespaces whose counters cannot be read through any member.
+func (nl *NetNamespaceList) GetForPids(pids []int) error { //nolint:staticcheck
+	index := make(map[uint64]int)
+	list := make([]NetNamespace, 0, len(nl.List))
+
+	for _, pid := range pids {
+		inode, err := procNsInode(pid, "net")
+		if err != nil {
+			continue
+		}
+
+		if i, ok := index[inode]; ok {
+			list[i].Pids = append(list[i].Pids, pid)
+			continue
+		}
+
+		index[inode] = len(list)
+		list = append(list, NetNamespace{Inode: inode, Pids: []int{pid}})
+	}
+
+	readable := list[:0]
+	for _, ns := range list {
+		// Any member will do, but a member might exit meanwhile.
+		for _, pid := range ns.Pids {
+			if err := ns.Ifaces.GetForPid(pid); err != nil {
+				continue
+			}
+			ns.SockStat.GetForPid(pid) //nolint:errcheck
+			readable = append(readable, ns)
+			break
+		}
+	}
+
+	nl.List = readable
+
+	return nil
+}
+
+func (rl *NetRouteList) Get() error { //nolint:staticcheck
+	capacity := len(rl.List)
+	if capacity == 0 {
+		capacity = 8
+	}
+	list := make([]NetRoute, 0, capacity)
+
+	// Iface Destination Gateway Flags RefCnt Use Metric Mask MTU Window IRTT
+	err := readFile(Procd+"/net/route", func(line string) bool {
+		fields := strings.Fields(line)
+		if len(fields) < 8 || fields[0] == "Iface" {
+			return true
+		}
+
+		route := NetRoute{}
+		route.Iface = fields[0]
+		route.Destination = parseHexIPv4(fields[1])
+		route.Gateway = parseHexIPv4(fields[2])
+		route.Mask = net.IPMask(parseHexIPv4(fields[7]))
+
+		flags, _ := strconv.ParseUint(fields[3], 16, 32) //nolint:errcheck
+		route.Flags = uint32(flags)
+
+		metric, _ := strconv.ParseUint(fields[6], 10, 32) //nolint:errcheck
+		route.Metric = uint32(metric)
+
+		list = append(list, route)
+
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	// dest dest_plen src src_plen nexthop metric refcnt use flags iface
+	// The file is absent when IPv6 is disabled.
+	readFile(Procd+"/net/ipv6_route", func(line string) bool { //nolint:errcheck
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			return true
+		}
+
+		route := NetRoute{}
+		route.Iface = fields[9]
+		route.Destination = parseHexIPv6(fields[0])
+		route.Gateway = parseHexIPv6(fields[4])
+
+		plen, _ := strconv.ParseUint(fields[1], 16, 8) //nolint:errcheck
+		route.Mask = net.CIDRMask(int(plen), 128)
+
+		metric, _ := strconv.ParseUint(fields[5], 16, 32) //nolint:errcheck
+		route.Metric = uint32(metric)
+
+		flags, _ := strconv.ParseUint(fields[8], 16, 32) //nolint:errcheck
+		route.Flags = uint32(flags)
+
+		list = append(list, route)
+
+		return true
+	})
+
+	rl.List = list
+
+	return nil
+}
+
+func (nl *NetNeighborList) Get() error { //nolint:staticcheck
+	capacity := len(nl.List)
+	if capacity == 0 {
+		capacity = 8
+	}
+	list := make([]NetNeighbor, 0, capacity)
+
+	// IP address, HW type, Flags, HW address, Mask, Device
+	err := readFile(Procd+"/net/arp", func(line string) bool {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || fields[0] == "IP" {
+			return true
+		}
+
+		neighbor := NetNeighbor{}
+		neighbor.IP = net.ParseIP(fields[0])
+		neighbor.HardwareAddr, _ = net.ParseMAC(fields[3]) //nolint:errcheck
+		neighbor.Iface = fields[5]
+
+		flags, _ := strconv.ParseUint(strings.TrimPrefix(fields[2], "0x"), 16, 32) //nolint:errcheck
+		neighbor.Flags = uint32(flags)
+
+		// ATF_PERM and ATF_COM, see linux/if_arp.h
+		switch {
+		case neighbor.Flags&0x04 != 0:
+			neighbor.State = NeighborPermanent
+		case neighbor.Flags&0x02 != 0:
+			neighbor.State = NeighborReachable
+		default:
+			neighbor.State = NeighborIncomplete
+		}
+
+		list = append(list, neighbor)
+
+		return true
+	})
+
+	nl.List = list
+
+	return err
+}
+
 func (pl *ProcList) Get() error { //nolint:staticcheck
 	dir, err := os.Open(Procd)
 	if err != nil {
@@ -314,27 +598,78 @@ func (pl *ProcList) Get() error { //nolint:staticcheck
 	return nil
 }
 
-func (ps *ProcState) Get(pid int) error { //nolint:staticcheck
+func (s *ProcStat) Get(pid int) error { //nolint:staticcheck
 	contents, err := readProcFile(pid, "stat")
 	if err != nil {
 		return err
 	}
 
-	fields := strings.Fields(string(contents))
+	return parseProcStat(s, contents)
+}
+
+// ProcState returns the ProcState subset of the stat fields.
+func (s *ProcStat) ProcState() ProcState {
+	return ProcState{
+		Name:      s.Name,
+		State:     s.State,
+		Ppid:      s.Ppid,
+		Tty:       s.Tty,
+		Priority:  s.Priority,
+		Nice:      s.Nice,
+		Processor: s.Processor,
+	}
+}
+
+// ProcTime returns the ProcTime subset of the stat fields, converted
+// from clock ticks to milliseconds.
+func (s *ProcStat) ProcTime() ProcTime {
+	pt := ProcTime{}
+
+	// convert to millis
+	pt.User = s.Utime * (1000 / system.ticks)
+	pt.Sys = s.Stime * (1000 / system.ticks)
+	pt.Total = pt.User + pt.Sys
+
+	// convert to millis
+	pt.StartTime = s.StartTime / system.ticks
+	pt.StartTime += system.btime
+	pt.StartTime *= 1000
+
+	return pt
+}
 
-	ps.Name = fields[1][1 : len(fields[1])-1] // strip ()'s
+func (ps *ProcState) Get(pid int) error { //nolint:staticcheck
+	stat := ProcStat{}
+	if err := stat.Get(pid); err != nil {
+		return err
+	}
 
-	ps.State = RunState(fields[2][0])
+	*ps = stat.ProcState()
 
-	ps.Ppid, _ = strconv.Atoi(fields[3]) //nolint:errcheck
+	return nil
+}
 
-	ps.Tty, _ = strconv.Atoi(fields[6]) //nolint:errcheck
+func (ps *ProcStatus) Get(pid int) error { //nolint:staticcheck
+	contents, err := readProcFile(pid, "status")
+	if err != nil {
+		return err
+	}
 
-	ps.Priority, _ = strconv.Atoi(fields[17]) //nolint:errcheck
+	return parseProcStatus(ps, contents)
+}
 
-	ps.Nice, _ = strconv.Atoi(fields[18]) //nolint:errcheck
+func (pc *ProcCred) Get(pid int) error { //nolint:staticcheck
+	status := ProcStatus{}
+	if err := status.Get(pid); err != nil {
+		return err
+	}
 
-	ps.Processor, _ = strconv.Atoi(fields[38]) //nolint:errcheck
+	pc.Uid = status.Uid
+	pc.Gid = status.Gid
+	pc.Euid = status.Euid
+	pc.Egid = status.Egid
+	pc.User = userNames.lookup(Etcd+"/passwd", status.Euid)
+	pc.Group = groupNames.lookup(Etcd+"/group", status.Egid)
 
 	return nil
 }
@@ -356,44 +691,183 @@ func (pm *ProcMem) Get(pid int) error { //nolint:staticcheck
 	share, _ := strtoull(fields[2]) //nolint:errcheck
 	pm.Share = share << 12
 
-	contents, err = readProcFile(pid, "stat")
-	if err != nil {
+	stat := ProcStat{}
+	if err := stat.Get(pid); err != nil {
 		return err
 	}
 
-	fields = strings.Fields(string(contents))
-
-	pm.MinorFaults, _ = strtoull(fields[10]) //nolint:errcheck
-	pm.MajorFaults, _ = strtoull(fields[12]) //nolint:errcheck
+	pm.MinorFaults = stat.MinFlt
+	pm.MajorFaults = stat.MajFlt
 	pm.PageFaults = pm.MinorFaults + pm.MajorFaults
 
 	return nil
 }
 
+func (pm *ProcMemDetail) Get(pid int) error { //nolint:staticcheck
+	// smaps_rollup is available since Linux 4.14, on older kernels
+	// the totals are summed over all mappings in smaps.
+	contents, err := readProcFile(pid, "smaps_rollup")
+	if errors.Is(err, syscall.ESRCH) {
+		contents, err = readProcFile(pid, "smaps")
+		if errors.Is(err, syscall.ESRCH) {
+			return err
+		}
+	}
+	if err != nil {
+		return procPermissionError(pid, "smaps", err)
+	}
+
+	*pm = ProcMemDetail{}
+	parseSmapsTotals(contents, pm.table())
+	pm.Uss = pm.PrivateClean + pm.PrivateDirty
+
+	return nil
+}
+
+func (pm *ProcMemDetail) table() map[string]*uint64 {
+	return map[string]*uint64{
+		"Rss":           &pm.Rss,
+		"Pss":           &pm.Pss,
+		"Pss_Anon":      &pm.PssAnon,
+		"Pss_File":      &pm.PssFile,
+		"Pss_Shmem":     &pm.PssShmem,
+		"Shared_Clean":  &pm.SharedClean,
+		"Shared_Dirty":  &pm.SharedDirty,
+		"Private_Clean": &pm.PrivateClean,
+		"Private_Dirty": &pm.PrivateDirty,
+		"Swap":          &pm.Swap,
+		"SwapPss":       &pm.SwapPss,
+		"AnonHugePages": &pm.AnonHugePages,
+		"Locked":        &pm.Locked,
+	}
+}
+
+func (ml *ProcMapList) Get(pid int) error { //nolint:staticcheck
+	contents, err := readProcFile(pid, "maps")
+	if err != nil {
+		return procPermissionError(pid, "maps", err)
+	}
+
+	ml.List = parseProcMaps(contents, len(ml.List))
+
+	return nil
+}
+
+// GetWithUsage lists the mappings together with their Rss, Pss and
+// Swap, read from /proc/<pid>/smaps.
+func (ml *ProcMapList) GetWithUsage(pid int) error { //nolint:staticcheck
+	contents, err := readProcFile(pid, "smaps")
+	if err != nil {
+		return procPermissionError(pid, "smaps", err)
+	}
+
+	ml.List = parseProcMaps(contents, len(ml.List))
+
+	return nil
+}
+
 func (pt *ProcTime) Get(pid int) error { //nolint:staticcheck
-	contents, err := readProcFile(pid, "stat")
+	stat := ProcStat{}
+	if err := stat.Get(pid); err != nil {
+		return err
+	}
+
+	*pt = stat.ProcTime()
+
+	return nil
+}
+
+func (pio *ProcIO) Get(pid int) error { //nolint:staticcheck
+	contents, err := readProcFile(pid, "io")
+	if err != nil {
+		return procPermissionError(pid, "io", err)
+	}
+
+	table := map[string]*uint64{
+		"rchar":                 &pio.Rchar,
+		"wchar":                 &pio.Wchar,
+		"syscr":                 &pio.Syscr,
+		"syscw":                 &pio.Syscw,
+		"read_bytes":            &pio.ReadBytes,
+		"write_bytes":           &pio.WriteBytes,
+		"cancelled_write_bytes": &pio.CancelledWriteBytes,
+	}
+
+	for _, line := range strings.Split(string(contents), "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		if ptr := table[key]; ptr != nil {
+			*ptr, _ = strtoull(strings.TrimSpace(value)) //nolint:errcheck
+		}
+	}
+
+	return nil
+}
+
+func (tl *ProcThreadList) Get(pid int) error { //nolint:staticcheck
+	names, err := readProcDirnames(pid, "task")
 	if err != nil {
 		return err
 	}
 
-	fields := strings.Fields(string(contents))
+	list := make([]ProcThread, 0, len(names))
 
-	user, _ := strtoull(fields[13]) //nolint:errcheck
-	sys, _ := strtoull(fields[14])  //nolint:errcheck
-	// convert to millis
-	pt.User = user * (1000 / system.ticks)
-	pt.Sys = sys * (1000 / system.ticks)
-	pt.Total = pt.User + pt.Sys
+	for _, name := range names {
+		tid, err := strconv.Atoi(name)
+		if err != nil {
+			continue
+		}
 
-	// convert to millis
-	pt.StartTime, _ = strtoull(fields[21]) //nolint:errcheck
-	pt.StartTime /= system.ticks
-	pt.StartTime += system.btime
-	pt.StartTime *= 1000
+		thread, err := getProcThread(pid, tid)
+		if err != nil {
+			// The thread exited meanwhile
+			continue
+		}
+
+		list = append(list, thread)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Tid < list[j].Tid
+	})
+
+	tl.List = list
 
 	return nil
 }
 
+func getProcThread(pid, tid int) (ProcThread, error) {
+	taskDir := "task/" + strconv.Itoa(tid) + "/"
+
+	contents, err := readProcFile(pid, taskDir+"stat")
+	if err != nil {
+		return ProcThread{}, err
+	}
+
+	stat := ProcStat{}
+	if err := parseProcStat(&stat, contents); err != nil {
+		return ProcThread{}, err
+	}
+
+	thread := ProcThread{
+		Tid:       tid,
+		ProcState: stat.ProcState(),
+		ProcTime:  stat.ProcTime(),
+	}
+
+	contents, err = readProcFile(pid, taskDir+"status")
+	if err == nil {
+		status := ProcStatus{}
+		parseProcStatus(&status, contents) //nolint:errcheck
+		thread.VoluntaryCtxtSwitches = status.VoluntaryCtxtSwitches
+		thread.NonvoluntaryCtxtSwitches = status.NonvoluntaryCtxtSwitches
+	}
+
+	return thread, nil
+}
+
 func (pa *ProcArgs) Get(pid int) error { //nolint:staticcheck
 	contents, err := readProcFile(pid, "cmdline")
 	if err != nil {
@@ -417,40 +891,316 @@ func (pa *ProcArgs) Get(pid int) error { //nolint:staticcheck
 	return nil
 }
 
-func (pe *ProcExe) Get(pid int) error { //nolint:staticcheck
-	fields := map[string]*string{
-		"exe":  &pe.Name,
-		"cwd":  &pe.Cwd,
-		"root": &pe.Root,
+func (pe *ProcEnv) Get(pid int) error { //nolint:staticcheck
+	contents, err := readProcFile(pid, "environ")
+	if err != nil {
+		return procPermissionError(pid, "environ", err)
 	}
 
-	for name, field := range fields {
-		val, err := os.Readlink(procFileName(pid, name))
+	list := make([]string, 0, bytes.Count(contents, []byte{0}))
+	vars := make(map[string]string, cap(list))
 
-		if err != nil {
-			return err
+	for _, entry := range bytes.Split(contents, []byte{0}) {
+		if len(entry) == 0 {
+			continue
 		}
 
-		*field = val
+		env := string(entry)
+		list = append(list, env)
+
+		if name, value, found := strings.Cut(env, "="); found {
+			vars[name] = value
+		}
 	}
 
+	pe.List = list
+	pe.Vars = vars
+
 	return nil
 }
 
-func determineSwapUsage(cgroup string) (uint64, error) {
-	// Check v2 over v1
-	usageAsString, err := os.ReadFile(Sysd2 + cgroup + "/memory.swap.current")
-	if err == nil {
-		return strtoull(strings.Split(string(usageAsString), "\n")[0])
+func (fl *ProcFdList) Get(pid int) error { //nolint:staticcheck
+	names, err := readProcDirnames(pid, "fd")
+	if err != nil {
+		return procPermissionError(pid, "fd", err)
 	}
 
-	var swap uint64
-	table := map[string]*uint64{
-		"swap": &swap,
-	}
+	list := make([]ProcFd, 0, len(names))
 
-	err, found := parseCgroupMeminfo(Sysd1+cgroup, table)
-	if err == nil {
+	for _, name := range names {
+		fd, err := strconv.Atoi(name)
+		if err != nil {
+			continue
+		}
+
+		entry, err := getProcFd(pid, fd)
+		if err != nil {
+			if errors.Is(err, os.ErrPermission) {
+				return procPermissionError(pid, "fd", err)
+			}
+			// The descriptor was closed meanwhile
+			continue
+		}
+
+		list = append(list, entry)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Fd < list[j].Fd
+	})
+
+	fl.List = list
+
+	return nil
+}
+
+func getProcFd(pid, fd int) (ProcFd, error) {
+	name := "fd/" + strconv.Itoa(fd)
+
+	target, err := os.Readlink(procFileName(pid, name))
+	if err != nil {
+		return ProcFd{}, err
+	}
+
+	entry := ProcFd{Fd: fd, Target: target}
+
+	// Pseudo files are linked as `type:[inode]` or `anon_inode:name`
+	kind, val, found := strings.Cut(target, ":")
+	if found && !strings.HasPrefix(target, "/") {
+		val = strings.TrimSuffix(strings.TrimPrefix(val, "["), "]")
+		switch kind {
+		case "socket":
+			entry.Type = ProcFdSocket
+			entry.Inode, _ = strtoull(val) //nolint:errcheck
+		case "pipe":
+			entry.Type = ProcFdPipe
+			entry.Inode, _ = strtoull(val) //nolint:errcheck
+		case "anon_inode":
+			entry.Type = ProcFdAnonInode
+			entry.AnonInode = val
+		}
+	} else if info, err := os.Stat(procFileName(pid, name)); err == nil {
+		mode := info.Mode()
+		switch {
+		case mode.IsRegular():
+			entry.Type = ProcFdFile
+		case mode.IsDir():
+			entry.Type = ProcFdDir
+		case mode&os.ModeDevice != 0:
+			entry.Type = ProcFdDevice
+		case mode&os.ModeNamedPipe != 0:
+			entry.Type = ProcFdPipe
+		case mode&os.ModeSocket != 0:
+			entry.Type = ProcFdSocket
+		}
+	}
+
+	// pos and flags (octal) from fdinfo, which is absent before 2.6.22
+	contents, err := readProcFile(pid, "fdinfo/"+strconv.Itoa(fd))
+	if err == nil {
+		for _, line := range strings.Split(string(contents), "\n") {
+			key, value, found := strings.Cut(line, ":")
+			if !found {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch key {
+			case "pos":
+				entry.Pos, _ = strtoull(value) //nolint:errcheck
+			case "flags":
+				entry.Flags, _ = strconv.ParseUint(value, 8, 64) //nolint:errcheck
+			}
+		}
+	}
+
+	return entry, nil
+}
+
+func (fc *ProcFdCount) Get(pid int) error { //nolint:staticcheck
+	names, err := readProcDirnames(pid, "fd")
+	if err != nil {
+		return procPermissionError(pid, "fd", err)
+	}
+
+	limits := ProcLimits{}
+	if err := limits.Get(pid); err != nil {
+		return err
+	}
+
+	fc.Count = uint64(len(names))
+	fc.SoftLimit = limits.OpenFiles.Soft
+	fc.HardLimit = limits.OpenFiles.Hard
+
+	return nil
+}
+
+func (pl *ProcLimits) Get(pid int) error { //nolint:staticcheck
+	contents, err := readProcFile(pid, "limits")
+	if err != nil {
+		return err
+	}
+
+	table := map[string]*Rlimit{
+		"Max cpu time":          &pl.Cpu,
+		"Max file size":         &pl.FileSize,
+		"Max data size":         &pl.Data,
+		"Max stack size":        &pl.Stack,
+		"Max core file size":    &pl.Core,
+		"Max resident set":      &pl.Rss,
+		"Max processes":         &pl.Processes,
+		"Max open files":        &pl.OpenFiles,
+		"Max locked memory":     &pl.LockedMemory,
+		"Max address space":     &pl.AddressSpace,
+		"Max file locks":        &pl.FileLocks,
+		"Max pending signals":   &pl.PendingSignals,
+		"Max msgqueue size":     &pl.MsgQueue,
+		"Max nice priority":     &pl.Nice,
+		"Max realtime priority": &pl.RtPriority,
+		"Max realtime timeout":  &pl.RtTimeout,
+	}
+
+	// Expected line syntax - `Max open files  soft  hard  [units]`,
+	// the name is padded to 26 columns.
+	for _, line := range strings.Split(string(contents), "\n") {
+		if len(line) < 26 {
+			continue
+		}
+		ptr := table[strings.TrimSpace(line[:26])]
+		if ptr == nil {
+			continue
+		}
+		fields := strings.Fields(line[26:])
+		if len(fields) < 2 {
+			continue
+		}
+		ptr.Soft = parseRlimit(fields[0])
+		ptr.Hard = parseRlimit(fields[1])
+	}
+
+	return nil
+}
+
+func (lu *ProcLimitsUsage) Get(pid int) error { //nolint:staticcheck
+	limits := ProcLimits{}
+	if err := limits.Get(pid); err != nil {
+		return err
+	}
+
+	status := ProcStatus{}
+	if err := status.Get(pid); err != nil {
+		return err
+	}
+
+	names, err := readProcDirnames(pid, "fd")
+	if err != nil {
+		return procPermissionError(pid, "fd", err)
+	}
+
+	lu.OpenFiles = ProcLimitUsage{Used: uint64(len(names)), Limit: limits.OpenFiles}
+	lu.Threads = ProcLimitUsage{Used: uint64(status.Threads), Limit: limits.Processes}
+	lu.AddressSpace = ProcLimitUsage{Used: status.VmSize, Limit: limits.AddressSpace}
+
+	return nil
+}
+
+func (pc *ProcCgroups) Get(pid int) error { //nolint:staticcheck
+	return procNotFound(pc.get(procFileName(pid, "cgroup")))
+}
+
+func (pc *ProcCgroups) get(file string) error {
+	var list []ProcCgroup
+
+	// Expected line syntax - id:controllers:path
+	// v1: `4:memory:/path`, `1:name=systemd:/path`
+	// v2: `0::/path`
+	err := readFile(file, func(line string) bool {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) < 3 {
+			return true
+		}
+
+		cg := ProcCgroup{Path: strings.Trim(fields[2], " ")}
+		cg.HierarchyId, _ = strconv.Atoi(fields[0]) //nolint:errcheck
+		if fields[1] != "" {
+			cg.Controllers = strings.Split(fields[1], ",")
+		}
+
+		list = append(list, cg)
+
+		return true
+	})
+
+	pc.List = list
+
+	return err
+}
+
+func (pn *ProcNamespaces) Get(pid int) error { //nolint:staticcheck
+	if _, err := os.Stat(procFileName(pid, "ns")); err != nil {
+		return procNotFound(err)
+	}
+
+	table := map[string]*uint64{
+		"mnt":    &pn.Mnt,
+		"net":    &pn.Net,
+		"pid":    &pn.Pid,
+		"uts":    &pn.Uts,
+		"ipc":    &pn.Ipc,
+		"user":   &pn.User,
+		"cgroup": &pn.Cgroup,
+		"time":   &pn.Time,
+	}
+
+	for name, ptr := range table {
+		inode, err := procNsInode(pid, name)
+		if err != nil {
+			if errors.Is(err, os.ErrPermission) {
+				return procPermissionError(pid, "ns/"+name, err)
+			}
+			// Not supported by this kernel
+			*ptr = 0
+			continue
+		}
+		*ptr = inode
+	}
+
+	return nil
+}
+
+func (pe *ProcExe) Get(pid int) error { //nolint:staticcheck
+	fields := map[string]*string{
+		"exe":  &pe.Name,
+		"cwd":  &pe.Cwd,
+		"root": &pe.Root,
+	}
+
+	for name, field := range fields {
+		val, err := os.Readlink(procFileName(pid, name))
+
+		if err != nil {
+			return err
+		}
+
+		*field = val
+	}
+
+	return nil
+}
+
+func determineSwapUsage(cgroup string) (uint64, error) {
+	// Check v2 over v1
+	usageAsString, err := os.ReadFile(Sysd2 + cgroup + "/memory.swap.current")
+	if err == nil {
+		return strtoull(strings.Split(string(usageAsString), "\n")[0])
+	}
+
+	var swap uint64
+	table := map[string]*uint64{
+		"swap": &swap,
+	}
+
+	err, found := parseCgroupMeminfo(Sysd1+cgroup, table)
+	if err == nil {
 		if !found {
 			// If no data was found, simply claim `zero swap used`.
 			return 0, errors.New("no data found")
@@ -519,45 +1269,19 @@ func determineMemoryLimit(cgroup string) (uint64, error) {
 }
 
 func determineSelfCgroup(cgroup *string) error {
-	// - /proc/self/cgroup
-	//   Expected line syntax - id:tag:path
-	//   Three fields required in each line.
-
-	// Look for a cgroup v1 memory controller first
-	err := readFile(Procd+"/self/cgroup", func(line string) bool {
-		fields := strings.Split(line, ":")
-		// Match: `*:memory:/path`
-		if len(fields) < 3 {
-			return true
-		}
-		if fields[1] == "memory" {
-			*cgroup = strings.Trim(fields[len(fields)-1], " ")
-		}
-		return true
-	})
-	if err != nil {
+	cgroups := ProcCgroups{}
+	if err := cgroups.get(Procd + "/self/cgroup"); err != nil {
 		return err
 	}
-	if *cgroup != "" {
-		return nil
-	}
 
-	// Fall back to a cgroup v2 memory controller
-	err = readFile(Procd+"/self/cgroup", func(line string) bool {
-		fields := strings.Split(line, ":")
-		// Match: `0::/path`
-		if len(fields) < 3 {
-			return true
-		}
-		if (fields[0] == "0") && (fields[1] == "") {
-			*cgroup = strings.Trim(fields[len(fields)-1], " ")
-		}
-		return true
-	})
-	if err != nil {
-		return err
+	// Look for a cgroup v1 memory controller first, then fall back
+	// to the cgroup v2 unified hierarchy.
+	if path, ok := cgroups.Controller("memory"); ok && path != "" {
+		*cgroup = path
+		return nil
 	}
-	if *cgroup != "" {
+	if path, ok := cgroups.Unified(); ok && path != "" {
+		*cgroup = path
 		return nil
 	}
 
@@ -601,6 +1325,488 @@ func parseCgroupMeminfo(cgroupDir string, table map[string]*uint64) (error, bool
 	return nil, found
 }
 
+func parseSockStat(file string, table map[string]*uint64) error {
+	// Expected line syntax - `PROTO: key value key value ...`
+	return readFile(file, func(line string) bool {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return true
+		}
+
+		for i := 1; i+1 < len(fields); i += 2 {
+			if ptr := table[fields[0]+fields[i]]; ptr != nil {
+				val, err := strtoull(fields[i+1])
+				if err == nil {
+					*ptr = val
+				}
+			}
+		}
+
+		return true
+	})
+}
+
+func parseSockMemLimits(file string, limits *SockMemLimits) error {
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	fields := strings.Fields(string(contents))
+	if len(fields) < 3 {
+		return errors.New("unexpected format in " + file)
+	}
+
+	limits.Min, _ = strtoull(fields[0])      //nolint:errcheck
+	limits.Pressure, _ = strtoull(fields[1]) //nolint:errcheck
+	limits.Max, _ = strtoull(fields[2])      //nolint:errcheck
+
+	return nil
+}
+
+// parseHexIPv4 decodes an address as printed by the kernel in
+// /proc/net/route, i.e. a host byte order 32 bit hex value.
+func parseHexIPv4(val string) net.IP {
+	addr, err := strconv.ParseUint(val, 16, 32)
+	if err != nil {
+		return nil
+	}
+
+	ip := make(net.IP, net.IPv4len)
+	binary.NativeEndian.PutUint32(ip, uint32(addr))
+
+	return ip
+}
+
+// parseHexIPv6 decodes an address as printed by the kernel in
+// /proc/net/ipv6_route, i.e. 32 hex digits in network byte order.
+func parseHexIPv6(val string) net.IP {
+	ip, err := hex.DecodeString(val)
+	if err != nil || len(ip) != net.IPv6len {
+		return nil
+	}
+
+	return net.IP(ip)
+}
+
+// parseProcStat parses the contents of /proc/<pid>/stat. The comm
+// field is delimited by the first '(' and the last ')', since the
+// name itself may contain spaces and parentheses.
+func parseProcStat(self *ProcStat, contents []byte) error {
+	line := string(contents)
+
+	open := strings.IndexByte(line, '(')
+	end := strings.LastIndexByte(line, ')')
+	if open < 0 || end < open {
+		return errors.New("unexpected format in stat: missing comm")
+	}
+
+	// Fields after comm, starting with state (field 3)
+	fields := strings.Fields(line[end+1:])
+	if len(fields) < 20 {
+		return errors.New("unexpected format in stat: too few fields")
+	}
+
+	self.Pid, _ = strconv.Atoi(strings.TrimSpace(line[:open])) //nolint:errcheck
+	self.Name = line[open+1 : end]
+	self.State = RunState(fields[0][0])
+
+	ints := []*int{
+		&self.Ppid, &self.Pgrp, &self.Session, &self.Tty, &self.Tpgid,
+	}
+	for i, ptr := range ints {
+		*ptr, _ = strconv.Atoi(fields[1+i]) //nolint:errcheck
+	}
+
+	// Fields are named after proc(5), the index is relative to state.
+	table := []struct {
+		index int
+		ptr   interface{}
+	}{
+		{6, &self.Flags},
+		{7, &self.MinFlt},
+		{8, &self.CMinFlt},
+		{9, &self.MajFlt},
+		{10, &self.CMajFlt},
+		{11, &self.Utime},
+		{12, &self.Stime},
+		{13, &self.Cutime},
+		{14, &self.Cstime},
+		{15, &self.Priority},
+		{16, &self.Nice},
+		{17, &self.NumThreads},
+		{18, &self.ItRealValue},
+		{19, &self.StartTime},
+		{20, &self.Vsize},
+		{21, &self.Rss},
+		{22, &self.RssLim},
+		{23, &self.StartCode},
+		{24, &self.EndCode},
+		{25, &self.StartStack},
+		{26, &self.KstkEsp},
+		{27, &self.KstkEip},
+		{28, &self.Signal},
+		{29, &self.Blocked},
+		{30, &self.SigIgnore},
+		{31, &self.SigCatch},
+		{32, &self.Wchan},
+		{33, &self.Nswap},
+		{34, &self.CNswap},
+		{35, &self.ExitSignal},
+		{36, &self.Processor},
+		{37, &self.RtPriority},
+		{38, &self.Policy},
+		{39, &self.DelayAcctBlkioTicks},
+		{40, &self.GuestTime},
+		{41, &self.CGuestTime},
+		{42, &self.StartData},
+		{43, &self.EndData},
+		{44, &self.StartBrk},
+		{45, &self.ArgStart},
+		{46, &self.ArgEnd},
+		{47, &self.EnvStart},
+		{48, &self.EnvEnd},
+		{49, &self.ExitCode},
+	}
+
+	// Older kernels print fewer fields, the missing ones stay zero.
+	for _, entry := range table {
+		if entry.index >= len(fields) {
+			break
+		}
+		switch ptr := entry.ptr.(type) {
+		case *uint64:
+			*ptr, _ = strtoull(fields[entry.index]) //nolint:errcheck
+		case *int64:
+			*ptr, _ = strconv.ParseInt(fields[entry.index], 10, 64) //nolint:errcheck
+		case *int:
+			*ptr, _ = strconv.Atoi(fields[entry.index]) //nolint:errcheck
+		}
+	}
+
+	return nil
+}
+
+func parseProcStatus(self *ProcStatus, contents []byte) error {
+	sizes := map[string]*uint64{
+		"VmPeak":   &self.VmPeak,
+		"VmSize":   &self.VmSize,
+		"VmLck":    &self.VmLck,
+		"VmPin":    &self.VmPin,
+		"VmHWM":    &self.VmHWM,
+		"VmRSS":    &self.VmRSS,
+		"RssAnon":  &self.RssAnon,
+		"RssFile":  &self.RssFile,
+		"RssShmem": &self.RssShmem,
+		"VmData":   &self.VmData,
+		"VmStk":    &self.VmStk,
+		"VmExe":    &self.VmExe,
+		"VmLib":    &self.VmLib,
+		"VmPTE":    &self.VmPTE,
+		"VmSwap":   &self.VmSwap,
+	}
+	masks := map[string]*uint64{
+		"SigPnd": &self.SigPnd,
+		"ShdPnd": &self.ShdPnd,
+		"SigBlk": &self.SigBlk,
+		"SigIgn": &self.SigIgn,
+		"SigCgt": &self.SigCgt,
+		"CapInh": &self.CapInh,
+		"CapPrm": &self.CapPrm,
+		"CapEff": &self.CapEff,
+		"CapBnd": &self.CapBnd,
+		"CapAmb": &self.CapAmb,
+	}
+	counters := map[string]*uint64{
+		"voluntary_ctxt_switches":    &self.VoluntaryCtxtSwitches,
+		"nonvoluntary_ctxt_switches": &self.NonvoluntaryCtxtSwitches,
+	}
+	ints := map[string]*int{
+		"Tgid":      &self.Tgid,
+		"Pid":       &self.Pid,
+		"PPid":      &self.Ppid,
+		"TracerPid": &self.TracerPid,
+		"FDSize":    &self.FDSize,
+		"Threads":   &self.Threads,
+
+		"Seccomp":         &self.Seccomp,
+		"Seccomp_filters": &self.SeccompFilters,
+	}
+	ids := map[string][]*int{
+		"Uid": {&self.Uid, &self.Euid, &self.Suid, &self.FsUid},
+		"Gid": {&self.Gid, &self.Egid, &self.Sgid, &self.FsGid},
+	}
+
+	// Expected line syntax - `Key:\tvalue`
+	for _, line := range strings.Split(string(contents), "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		if ptr := sizes[key]; ptr != nil {
+			val, err := strtoull(strings.TrimSuffix(value, " kB"))
+			if err == nil {
+				*ptr = val * 1024
+			}
+			continue
+		}
+		if ptr := masks[key]; ptr != nil {
+			*ptr, _ = strconv.ParseUint(value, 16, 64) //nolint:errcheck
+			continue
+		}
+		if ptr := counters[key]; ptr != nil {
+			*ptr, _ = strtoull(value) //nolint:errcheck
+			continue
+		}
+		if ptr := ints[key]; ptr != nil {
+			*ptr, _ = strconv.Atoi(value) //nolint:errcheck
+			continue
+		}
+		if ptrs := ids[key]; ptrs != nil {
+			for i, field := range strings.Fields(value) {
+				if i < len(ptrs) {
+					*ptrs[i], _ = strconv.Atoi(field) //nolint:errcheck
+				}
+			}
+			continue
+		}
+
+		switch key {
+		case "Name":
+			self.Name = value
+		case "State":
+			if len(value) > 0 {
+				self.State = RunState(value[0])
+			}
+		case "Groups":
+			self.Groups = parseIntFields(value)
+		case "NStgid":
+			self.NStgid = parseIntFields(value)
+		case "NSpid":
+			self.NSpid = parseIntFields(value)
+		case "NoNewPrivs":
+			self.NoNewPrivs = value == "1"
+		case "Cpus_allowed_list":
+			self.CpusAllowedList, _ = parseCpuList(value) //nolint:errcheck
+		case "Mems_allowed_list":
+			self.MemsAllowedList, _ = parseCpuList(value) //nolint:errcheck
+		}
+	}
+
+	if self.Name == "" {
+		return errors.New("unexpected format in status: missing name")
+	}
+
+	return nil
+}
+
+func parseIntFields(val string) []int {
+	fields := strings.Fields(val)
+	list := make([]int, 0, len(fields))
+
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err == nil {
+			list = append(list, n)
+		}
+	}
+
+	return list
+}
+
+// parseCpuList parses the list format used for cpu and node sets,
+// e.g. `0-3,8,10-11`.
+func parseCpuList(val string) ([]int, error) {
+	var list []int
+
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return list, nil
+	}
+
+	for _, part := range strings.Split(val, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+
+		lo, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, err
+		}
+		hi := lo
+		if isRange {
+			hi, err = strconv.Atoi(last)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for n := lo; n <= hi; n++ {
+			list = append(list, n)
+		}
+	}
+
+	return list, nil
+}
+
+// idNameTable maps the numeric ids of a passwd(5) or group(5) style
+// file to names. It is parsed directly rather than through os/user so
+// that static builds without NSS work, and is reloaded whenever the
+// file's path, size or modification time changes.
+type idNameTable struct {
+	mu    sync.Mutex
+	file  string
+	size  int64
+	mtime time.Time
+	names map[int]string
+}
+
+func (t *idNameTable) lookup(file string, id int) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.names = nil
+	} else if t.names == nil || t.file != file ||
+		t.size != info.Size() || !t.mtime.Equal(info.ModTime()) {
+		t.file = file
+		t.size = info.Size()
+		t.mtime = info.ModTime()
+		t.names = parseIdNames(file)
+	}
+
+	if name, ok := t.names[id]; ok {
+		return name
+	}
+
+	return strconv.Itoa(id)
+}
+
+func parseIdNames(file string) map[int]string {
+	names := make(map[int]string)
+
+	// Expected line syntax - name:password:id:...
+	readFile(file, func(line string) bool { //nolint:errcheck
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") {
+			return true
+		}
+
+		id, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return true
+		}
+
+		// The first entry wins, as with getpwuid(3)
+		if _, ok := names[id]; !ok {
+			names[id] = fields[0]
+		}
+
+		return true
+	})
+
+	return names
+}
+
+// parseSmapsTotals adds up the `Key: N kB` lines of smaps or
+// smaps_rollup found in table, in bytes.
+func parseSmapsTotals(contents []byte, table map[string]*uint64) {
+	for _, line := range strings.Split(string(contents), "\n") {
+		key, value, ok := parseSmapsLine(line)
+		if !ok {
+			continue
+		}
+		if ptr := table[key]; ptr != nil {
+			*ptr += value
+		}
+	}
+}
+
+// parseSmapsLine parses a `Key: N kB` line, value in bytes.
+func parseSmapsLine(line string) (string, uint64, bool) {
+	key, value, found := strings.Cut(line, ":")
+	if !found {
+		return "", 0, false
+	}
+	val, err := strtoull(strings.TrimSuffix(strings.TrimSpace(value), " kB"))
+	if err != nil {
+		return "", 0, false
+	}
+	return key, val * 1024, true
+}
+
+// parseProcMaps parses maps, or smaps where each mapping is followed
+// by `Key: N kB` lines.
+func parseProcMaps(contents []byte, capacity int) []ProcMap {
+	if capacity == 0 {
+		capacity = 64
+	}
+	list := make([]ProcMap, 0, capacity)
+
+	for _, line := range strings.Split(string(contents), "\n") {
+		if m, ok := parseProcMapsLine(line); ok {
+			list = append(list, m)
+			continue
+		}
+		if len(list) == 0 {
+			continue
+		}
+
+		key, value, ok := parseSmapsLine(line)
+		if !ok {
+			continue
+		}
+
+		m := &list[len(list)-1]
+		switch key {
+		case "Rss":
+			m.Rss += value
+		case "Pss":
+			m.Pss += value
+		case "Swap":
+			m.Swap += value
+		}
+	}
+
+	return list
+}
+
+// parseProcMapsLine parses a mapping of the form
+// `start-end perms offset dev inode [path]`.
+func parseProcMapsLine(line string) (ProcMap, bool) {
+	fields := strings.SplitN(line, " ", 6)
+	if len(fields) < 5 {
+		return ProcMap{}, false
+	}
+
+	start, end, found := strings.Cut(fields[0], "-")
+	if !found {
+		return ProcMap{}, false
+	}
+
+	m := ProcMap{
+		Perms: fields[1],
+		Dev:   fields[3],
+	}
+
+	var err error
+	if m.Start, err = strconv.ParseUint(start, 16, 64); err != nil {
+		return ProcMap{}, false
+	}
+	if m.End, err = strconv.ParseUint(end, 16, 64); err != nil {
+		return ProcMap{}, false
+	}
+	m.Offset, _ = strconv.ParseUint(fields[2], 16, 64) //nolint:errcheck
+	m.Inode, _ = strtoull(fields[4])                   //nolint:errcheck
+
+	if len(fields) == 6 {
+		m.Path = strings.TrimSpace(fields[5])
+	}
+
+	return m, true
+}
+
 func parseCpuStat(self *Cpu, line string) error {
 	fields := strings.Fields(line)
 
@@ -650,16 +1856,76 @@ func readProcFile(pid int, name string) ([]byte, error) {
 	contents, err := os.ReadFile(path)
 
 	if err != nil {
-		if perr, ok := err.(*os.PathError); ok {
-			if perr.Err == syscall.ENOENT {
-				return nil, syscall.ESRCH
-			}
-		}
+		return nil, procNotFound(err)
 	}
 
 	return contents, err
 }
 
+func readProcDirnames(pid int, name string) ([]string, error) {
+	dir, err := os.Open(procFileName(pid, name))
+	if err != nil {
+		return nil, procNotFound(err)
+	}
+	defer dir.Close() //nolint:errcheck
+
+	const readAllDirnames = -1 // see os.File.Readdirnames doc
+
+	return dir.Readdirnames(readAllDirnames)
+}
+
+func parseRlimit(val string) uint64 {
+	if val == "unlimited" {
+		return RlimitInfinity
+	}
+	limit, _ := strtoull(val) //nolint:errcheck
+	return limit
+}
+
+// procNotFound maps a missing /proc/<pid> entry to ESRCH.
+func procNotFound(err error) error {
+	if perr, ok := err.(*os.PathError); ok {
+		if perr.Err == syscall.ENOENT {
+			return syscall.ESRCH
+		}
+	}
+
+	return err
+}
+
+// procGone reports whether pid exited, which tells a vanished process
+// apart from a missing file of a live one.
+func procGone(pid int) bool {
+	_, err := os.Stat(procFileName(pid, "stat"))
+	return errors.Is(procNotFound(err), syscall.ESRCH)
+}
+
+// procPermissionError maps EACCES and EPERM reading /proc/<pid>/name
+// to a PermissionError.
+func procPermissionError(pid int, name string, err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return &PermissionError{Pid: pid, Name: name, Err: err}
+	}
+
+	return err
+}
+
+// procNsInode returns the inode identifying the namespace of the
+// given type, parsed from the `type:[inode]` link in /proc/<pid>/ns.
+func procNsInode(pid int, ns string) (uint64, error) {
+	link, err := os.Readlink(procFileName(pid, "ns/"+ns))
+	if err != nil {
+		return 0, procNotFound(err)
+	}
+
+	val := strings.TrimPrefix(link, ns+":[")
+	if len(val) == len(link) || !strings.HasSuffix(val, "]") {
+		return 0, errors.New("unexpected namespace link " + link)
+	}
+
+	return strtoull(strings.TrimSuffix(val, "]"))
+}
+
 func determineControllerMounts(sysd1, sysd2 *string) {
 	// grab cgroup controller mount points
 	readFile(Procd+"/self/mounts", func(line string) bool { //nolint:errcheck
@@ -712,3 +1978,323 @@ func stringSliceContains(a []string, x string) bool {
 	}
 	return false
 }
+
+func (ps *ProcSched) Get(pid int) error { //nolint:staticcheck
+	stat := ProcStat{}
+	if err := stat.Get(pid); err != nil {
+		return err
+	}
+
+	ps.Policy = SchedPolicy(stat.Policy)
+	ps.RtPriority = int(stat.RtPriority)
+	ps.Nice = stat.Nice
+
+	set := unix.CPUSet{}
+	if err := unix.SchedGetaffinity(pid, &set); err != nil {
+		return err
+	}
+	ps.Affinity = nil
+	for cpu, count := 0, set.Count(); len(ps.Affinity) < count; cpu++ {
+		if set.IsSet(cpu) {
+			ps.Affinity = append(ps.Affinity, cpu)
+		}
+	}
+
+	prio, _, errno := unix.Syscall(unix.SYS_IOPRIO_GET, ioprioWhoProcess, uintptr(pid), 0)
+	if errno != 0 {
+		return errno
+	}
+	ps.IOPrioClass = IOPrioClass(prio >> ioprioClassShift)
+	ps.IOPrioLevel = int(prio & (1<<ioprioClassShift - 1))
+
+	// schedstat is missing if the kernel lacks CONFIG_SCHEDSTATS
+	ps.RunTime, ps.WaitTime, ps.Timeslices = 0, 0, 0
+	if contents, err := readProcFile(pid, "schedstat"); err == nil {
+		fields := strings.Fields(string(contents))
+		if len(fields) >= 3 {
+			run, _ := strtoull(fields[0])  //nolint:errcheck
+			wait, _ := strtoull(fields[1]) //nolint:errcheck
+			ps.RunTime = time.Duration(run)
+			ps.WaitTime = time.Duration(wait)
+			ps.Timeslices, _ = strtoull(fields[2]) //nolint:errcheck
+		}
+	}
+
+	return nil
+}
+
+func (t *ProcTty) Get(pid int) error { //nolint:staticcheck
+	stat := ProcStat{}
+	if err := stat.Get(pid); err != nil {
+		return err
+	}
+
+	*t = stat.ProcTty()
+
+	return nil
+}
+
+// ProcTty resolves the controlling terminal of the process, without
+// reading stat again.
+func (s *ProcStat) ProcTty() ProcTty {
+	t := ProcTty{
+		Nr:      s.Tty,
+		Session: s.Session,
+		Pgrp:    s.Pgrp,
+		Tpgid:   s.Tpgid,
+	}
+	t.Major, t.Minor = decodeTtyNr(s.Tty)
+
+	if t.Nr != 0 {
+		t.Name = ttyName(t.Major, t.Minor)
+	}
+
+	return t
+}
+
+// decodeTtyNr splits tty_nr into the device numbers, the minor is
+// stored in bits 31-20 and 7-0, the major in bits 19-8.
+func decodeTtyNr(nr int) (major, minor uint32) {
+	major = uint32(nr>>8) & 0xfff
+	minor = uint32(nr)&0xff | uint32(nr>>12)&0xfff00
+	return major, minor
+}
+
+// ttyDriver is a line of /proc/tty/drivers, e.g.
+//
+//	pty_slave            /dev/pts      136 0-1048575 pty:slave
+//	serial               /dev/ttyS       4      64 serial
+type ttyDriver struct {
+	prefix string
+	major  uint32
+	lo, hi uint64
+	single bool
+}
+
+// ttyDriverTable caches the parsed /proc/tty/drivers. As procfs gives
+// no modification time it is reloaded when a major number is not
+// found, e.g. after a driver module was loaded.
+type ttyDriverTable struct {
+	mu      sync.Mutex
+	file    string
+	drivers []ttyDriver
+}
+
+func (t *ttyDriverTable) lookup(file string, major uint32, minor uint64) (ttyDriver, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == file {
+		if driver, ok := findTtyDriver(t.drivers, major, minor); ok {
+			return driver, true
+		}
+	}
+
+	t.file = file
+	t.drivers = parseTtyDrivers(file)
+
+	return findTtyDriver(t.drivers, major, minor)
+}
+
+func findTtyDriver(drivers []ttyDriver, major uint32, minor uint64) (ttyDriver, bool) {
+	for _, driver := range drivers {
+		if driver.major == major && minor >= driver.lo && minor <= driver.hi {
+			return driver, true
+		}
+	}
+	return ttyDriver{}, false
+}
+
+func parseTtyDrivers(file string) []ttyDriver {
+	var drivers []ttyDriver
+
+	readFile(file, func(line string) bool { //nolint:errcheck
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			return true
+		}
+		major, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return true
+		}
+
+		first, last, single := fields[3], fields[3], true
+		if i := strings.IndexByte(fields[3], '-'); i >= 0 {
+			first, last, single = fields[3][:i], fields[3][i+1:], false
+		}
+		lo, err := strtoull(first)
+		if err != nil {
+			return true
+		}
+		hi, err := strtoull(last)
+		if err != nil {
+			return true
+		}
+
+		drivers = append(drivers, ttyDriver{
+			prefix: strings.TrimPrefix(fields[1], "/dev/"),
+			major:  uint32(major),
+			lo:     lo,
+			hi:     hi,
+			single: single,
+		})
+		return true
+	})
+
+	return drivers
+}
+
+// ttyName looks up the driver of the device in /proc/tty/drivers and
+// returns the first device named after it which exists in Devd.
+func ttyName(major, minor uint32) string {
+	driver, ok := ttyDrivers.lookup(Procd+"/tty/drivers", major, uint64(minor))
+	if !ok {
+		return ""
+	}
+
+	raw := strconv.FormatUint(uint64(minor), 10)
+	offset := strconv.FormatUint(uint64(minor)-driver.lo, 10)
+
+	var candidates []string
+	if driver.single {
+		candidates = append(candidates, driver.prefix)
+	}
+	candidates = append(candidates,
+		driver.prefix+raw, driver.prefix+"/"+raw,
+		driver.prefix+offset, driver.prefix+"/"+offset)
+
+	for _, candidate := range candidates {
+		if ttyDeviceMatches(Devd+"/"+candidate, major, minor) {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+// ttyStat is replaced in tests, which can not create device nodes.
+var ttyStat = unix.Stat
+
+// ttyDeviceMatches reports whether path is a character device with the
+// given device numbers.
+func ttyDeviceMatches(path string, major, minor uint32) bool {
+	var st unix.Stat_t
+	if err := ttyStat(path, &st); err != nil {
+		return false
+	}
+	if st.Mode&unix.S_IFMT != unix.S_IFCHR {
+		return false
+	}
+	return unix.Major(uint64(st.Rdev)) == major && unix.Minor(uint64(st.Rdev)) == minor
+}
+
+func (ps *ProcSecurity) Get(pid int) error { //nolint:staticcheck
+	status := ProcStatus{}
+	if err := status.Get(pid); err != nil {
+		return err
+	}
+
+	ps.Inheritable = CapabilitySet(status.CapInh)
+	ps.Permitted = CapabilitySet(status.CapPrm)
+	ps.Effective = CapabilitySet(status.CapEff)
+	ps.Bounding = CapabilitySet(status.CapBnd)
+	ps.Ambient = CapabilitySet(status.CapAmb)
+	ps.NoNewPrivs = status.NoNewPrivs
+	ps.Seccomp = SeccompMode(status.Seccomp)
+	ps.SeccompFilters = status.SeccompFilters
+
+	// Reading the label fails with EINVAL if no LSM is active, attr
+	// is missing if the kernel lacks CONFIG_SECURITY
+	ps.Label = ""
+	label, err := os.ReadFile(procFileName(pid, "attr/current"))
+	switch {
+	case err == nil:
+		ps.Label = strings.TrimRight(string(label), "\x00\n")
+	case errors.Is(err, syscall.EINVAL), errors.Is(err, os.ErrNotExist):
+	default:
+		return procPermissionError(pid, "attr/current", err)
+	}
+
+	return nil
+}
+
+// procControlError maps ESRCH and EPERM of a ProcControl syscall to
+// the typed errors.
+func procControlError(pid int, op string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, syscall.ESRCH):
+		return &NoSuchProcessError{Pid: pid, Op: op}
+	case errors.Is(err, os.ErrPermission):
+		return &PermissionError{Pid: pid, Op: op, Err: err}
+	}
+
+	return err
+}
+
+// Signal sends sig to pid, 0 only checks that pid exists.
+func (c ProcControl) Signal(pid int, sig syscall.Signal) error {
+	return procControlError(pid, "signal", unix.Kill(pid, sig))
+}
+
+// Renice sets the nice value of pid. As with renice(1) this applies
+// to the thread pid, threads started before keep their nice value.
+func (c ProcControl) Renice(pid int, nice int) error {
+	return procControlError(pid, "renice", unix.Setpriority(unix.PRIO_PROCESS, pid, nice))
+}
+
+// SetAffinity restricts pid to the given cpus.
+func (c ProcControl) SetAffinity(pid int, cpus []int) error {
+	set := unix.CPUSet{}
+	for _, cpu := range cpus {
+		set.Set(cpu)
+	}
+
+	return procControlError(pid, "set affinity of", unix.SchedSetaffinity(pid, &set))
+}
+
+// ioprioWhoProcess is IOPRIO_WHO_PROCESS, ioprioClassShift the
+// position of the class in an ioprio value, see ioprio_set(2).
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+)
+
+// SetIOPriority sets the I/O scheduling class and level (0-7) of pid.
+func (c ProcControl) SetIOPriority(pid int, class IOPrioClass, level int) error {
+	prio := int(class)<<ioprioClassShift | level
+
+	_, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(pid), uintptr(prio))
+	if errno != 0 {
+		return procControlError(pid, "set I/O priority of", errno)
+	}
+
+	return nil
+}
+
+// OpenPidfd opens a pidfd (Linux 5.3+) for the process, making
+// ProcControl.SignalID immune to pid reuse. Close releases it.
+func (id *ProcID) OpenPidfd() error {
+	fd, err := unix.PidfdOpen(id.Pid, 0)
+	if err != nil {
+		return procControlError(id.Pid, "open pidfd of", err)
+	}
+	file := os.NewFile(uintptr(fd), "pidfd")
+
+	// The pid may have been reused before it was opened
+	if err := id.Check(); err != nil {
+		file.Close() //nolint:errcheck
+		return err
+	}
+
+	id.Close() //nolint:errcheck
+	id.pidfd = file
+
+	return nil
+}
+
+func (c ProcControl) signalPidfd(id *ProcID, sig syscall.Signal) error {
+	err := unix.PidfdSendSignal(int(id.pidfd.Fd()), sig, nil, 0)
+	return procControlError(id.Pid, "signal", err)
+}
@@ -1,12 +1,20 @@
 package sigar
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"golang.org/x/sys/unix"
 )
 
 // Helpers. Create various system information files
@@ -19,6 +27,68 @@ func setupFile(path, contents string) {
 	Expect(err).ToNot(HaveOccurred())
 }
 
+// procStatFields are the fields of /proc/<pid>/stat as named in proc(5).
+var procStatFields = []string{
+	"pid", "comm", "state", "ppid", "pgrp", "session", "tty_nr", "tpgid",
+	"flags", "minflt", "cminflt", "majflt", "cmajflt", "utime", "stime",
+	"cutime", "cstime", "priority", "nice", "num_threads", "itrealvalue",
+	"starttime", "vsize", "rss", "rsslim", "startcode", "endcode",
+	"startstack", "kstkesp", "kstkeip", "signal", "blocked", "sigignore",
+	"sigcatch", "wchan", "nswap", "cnswap", "exit_signal", "processor",
+	"rt_priority", "policy", "delayacct_blkio_ticks", "guest_time",
+	"cguest_time", "start_data", "end_data", "start_brk", "arg_start",
+	"arg_end", "env_start", "env_end", "exit_code",
+}
+
+var procStatDefaults = map[string]string{
+	"comm": "worker", "state": "S", "ppid": "1", "pgrp": "1", "session": "1",
+	"tpgid": "-1", "utime": "1", "stime": "2", "priority": "20",
+	"num_threads": "1", "starttime": "100", "vsize": "4096", "rss": "10",
+}
+
+// procStatLine formats a stat line of pid with the given fields, others
+// take their defaults. The line ends after rss, as on older kernels,
+// or after the last field given.
+func procStatLine(pid int, fields map[string]string) string {
+	last := 23 // rss
+	for name := range fields {
+		i := -1
+		for j, field := range procStatFields {
+			if field == name {
+				i = j
+			}
+		}
+		Expect(i).ToNot(Equal(-1), "unknown stat field "+name)
+		if i > last {
+			last = i
+		}
+	}
+
+	values := make([]string, 0, last+1)
+	for _, name := range procStatFields[:last+1] {
+		value, ok := fields[name]
+		switch {
+		case ok:
+		case name == "pid":
+			value = strconv.Itoa(pid)
+		case procStatDefaults[name] != "":
+			value = procStatDefaults[name]
+		default:
+			value = "0"
+		}
+		if name == "comm" {
+			value = "(" + value + ")"
+		}
+		values = append(values, value)
+	}
+
+	return strings.Join(values, " ") + "\n"
+}
+
+func procStatSetup(pid int, fields map[string]string) {
+	setupFile(fmt.Sprintf("%s/%d/stat", procd, pid), procStatLine(pid, fields))
+}
+
 func cgroupSetup(contents string) {
 	setupFile(procd+"/self/cgroup", contents+"\n")
 }
@@ -116,6 +186,7 @@ var _ = Describe("sigarLinux", func() {
 		// Can share the directory, no overlap in files used
 		Procd = procd
 		Etcd = etcd
+		Devd = procd + "/dev"
 		Sysd1 = procd + "/memory"
 		Sysd2 = procd
 	})
@@ -123,6 +194,7 @@ var _ = Describe("sigarLinux", func() {
 	AfterEach(func() {
 		Procd = "/proc"
 		Etcd = "/etc"
+		Devd = "/dev"
 		Sysd1 = "/sys/fs/cgroup/unified"
 		Sysd2 = "/sys/fs/cgroup/memory"
 
@@ -1050,4 +1122,1982 @@ DirectMap2M:      333824 kB
 			})
 		})
 	})
+
+	Describe("SockStat", func() {
+		BeforeEach(func() {
+			setupFile(procd+"/net/sockstat", `sockets: used 290
+TCP: inuse 27 orphan 1 tw 5 alloc 31 mem 3
+UDP: inuse 9 mem 2
+UDPLITE: inuse 0
+RAW: inuse 0
+FRAG: inuse 1 memory 1024
+`)
+			setupFile(procd+"/net/sockstat6", `TCP6: inuse 4
+UDP6: inuse 3
+UDPLITE6: inuse 0
+RAW6: inuse 0
+FRAG6: inuse 2 memory 2048
+`)
+			setupFile(procd+"/sys/net/ipv4/tcp_mem", "70680\t94243\t141360\n")
+			setupFile(procd+"/sys/net/ipv4/udp_mem", "141363\t188486\t282726\n")
+		})
+
+		It("returns socket counts and memory limits", func() {
+			sockStat := SockStat{}
+			err := sockStat.Get()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(sockStat).To(Equal(SockStat{
+				SocketsUsed: 290,
+				TcpInUse:    27,
+				TcpOrphan:   1,
+				TcpTimeWait: 5,
+				TcpAlloc:    31,
+				TcpMem:      3,
+				Tcp6InUse:   4,
+				UdpInUse:    9,
+				UdpMem:      2,
+				Udp6InUse:   3,
+				FragInUse:   1,
+				FragMemory:  1024,
+				Frag6InUse:  2,
+				Frag6Memory: 2048,
+				TcpMemLimits: SockMemLimits{
+					Min: 70680, Pressure: 94243, Max: 141360,
+				},
+				UdpMemLimits: SockMemLimits{
+					Min: 141363, Pressure: 188486, Max: 282726,
+				},
+			}))
+			Expect(sockStat.TcpMemPressure()).To(BeNumerically("~", 3.0/94243, 1e-9))
+		})
+
+		It("tolerates missing IPv6 and limit files", func() {
+			Expect(os.Remove(procd + "/net/sockstat6")).To(Succeed())
+			Expect(os.RemoveAll(procd + "/sys")).To(Succeed())
+
+			sockStat := SockStat{}
+			err := sockStat.Get()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sockStat.TcpInUse).To(Equal(uint64(27)))
+			Expect(sockStat.Tcp6InUse).To(Equal(uint64(0)))
+			Expect(sockStat.TcpMemPressure()).To(Equal(0.0))
+		})
+
+		It("does not keep values of a previous call", func() {
+			sockStat := SockStat{}
+			Expect(sockStat.Get()).To(Succeed())
+
+			Expect(os.Remove(procd + "/net/sockstat6")).To(Succeed())
+			Expect(os.RemoveAll(procd + "/sys")).To(Succeed())
+			Expect(sockStat.Get()).To(Succeed())
+			Expect(sockStat.TcpInUse).To(Equal(uint64(27)))
+			Expect(sockStat.Tcp6InUse).To(BeZero())
+			Expect(sockStat.TcpMemLimits).To(BeZero())
+		})
+
+		It("fails when sockstat is missing", func() {
+			Expect(os.Remove(procd + "/net/sockstat")).To(Succeed())
+
+			sockStat := SockStat{}
+			Expect(sockStat.Get()).ToNot(Succeed())
+		})
+	})
+
+	Describe("NetRouteList", func() {
+		BeforeEach(func() {
+			setupFile(procd+"/net/route", `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth1	00000000	0101A8C0	0003	0	0	600	00000000	0	0	0
+eth0	00000000	010200C0	0003	0	0	100	00000000	0	0	0
+eth0	000200C0	00000000	0001	0	0	0	00FFFFFF	0	0	0
+`)
+			setupFile(procd+"/net/ipv6_route", `fd000000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000001 00000000 00000001     eth0
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 fd000000000000000000000000000001 00000010 00000001 00000000 00000003     eth0
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 00000000000000000000000000000000 ffffffff 00000001 00000000 00200200       lo
+`)
+		})
+
+		It("parses IPv4 and IPv6 routes", func() {
+			routes := NetRouteList{}
+			err := routes.Get()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(routes.List).To(HaveLen(6))
+
+			Expect(routes.List[2].Iface).To(Equal("eth0"))
+			Expect(routes.List[2].Destination.String()).To(Equal("192.0.2.0"))
+			Expect(routes.List[2].Mask.String()).To(Equal("ffffff00"))
+			Expect(routes.List[2].Flags).To(Equal(uint32(RouteFlagUp)))
+
+			Expect(routes.List[4].Destination.String()).To(Equal("::"))
+			Expect(routes.List[4].Gateway.String()).To(Equal("fd00::1"))
+			Expect(routes.List[4].Metric).To(Equal(uint32(16)))
+			Expect(routes.List[4].IsDefault()).To(BeTrue())
+			Expect(routes.List[5].IsDefault()).To(BeFalse())
+		})
+
+		It("finds the default route with the lowest metric", func() {
+			routes := NetRouteList{}
+			Expect(routes.Get()).To(Succeed())
+
+			route, found := routes.DefaultRoute()
+			Expect(found).To(BeTrue())
+			Expect(route.Gateway.String()).To(Equal("192.0.2.1"))
+
+			iface, found := routes.DefaultIface()
+			Expect(found).To(BeTrue())
+			Expect(iface).To(Equal("eth0"))
+		})
+
+		It("reports a missing default route", func() {
+			routes := NetRouteList{List: []NetRoute{}}
+			_, found := routes.DefaultIface()
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("NetNeighborList", func() {
+		BeforeEach(func() {
+			setupFile(procd+"/net/arp", `IP address       HW type     Flags       HW address            Mask     Device
+192.0.2.1        0x1         0x2         aa:bb:cc:dd:ee:ff     *        eth0
+192.0.2.7        0x1         0x0         00:00:00:00:00:00     *        eth0
+192.0.2.9        0x1         0x6         02:42:ac:11:00:02     *        eth1
+`)
+		})
+
+		It("parses the ARP table", func() {
+			neighbors := NetNeighborList{}
+			err := neighbors.Get()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(neighbors.List).To(HaveLen(3))
+
+			Expect(neighbors.List[0].IP.String()).To(Equal("192.0.2.1"))
+			Expect(neighbors.List[0].HardwareAddr.String()).To(Equal("aa:bb:cc:dd:ee:ff"))
+			Expect(neighbors.List[0].Iface).To(Equal("eth0"))
+			Expect(neighbors.List[0].State).To(Equal(NeighborReachable))
+			Expect(neighbors.List[1].State).To(Equal(NeighborIncomplete))
+			Expect(neighbors.List[2].State.String()).To(Equal("permanent"))
+		})
+	})
+
+	Describe("NetIfaceList", func() {
+		netDev := func(path, rxBytes string) {
+			setupFile(path, `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo: 3230850     574    0    0    0     0          0         0  3230850     574    0    0    0     0       0          0
+  eth0: `+rxBytes+`      31    1    2    3     4          5         6     2723      32    7    8    9    10      11         12
+`)
+		}
+
+		nsLink := func(pid, target string) {
+			_ = os.MkdirAll(procd+"/"+pid+"/ns", 0755) //nolint:errcheck
+			Expect(os.Symlink(target, procd+"/"+pid+"/ns/net")).To(Succeed())
+		}
+
+		It("parses the host interface counters", func() {
+			netDev(procd+"/net/dev", "2076")
+
+			ifaces := NetIfaceList{}
+			err := ifaces.Get()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ifaces.List).To(HaveLen(2))
+			Expect(ifaces.List[0].Name).To(Equal("lo"))
+			Expect(ifaces.List[1]).To(Equal(NetIface{
+				Name:    "eth0",
+				RxBytes: 2076, RxPackets: 31, RxErrors: 1, RxDropped: 2,
+				RxFifo: 3, RxFrame: 4, RxCompressed: 5, RxMulticast: 6,
+				TxBytes: 2723, TxPackets: 32, TxErrors: 7, TxDropped: 8,
+				TxFifo: 9, TxCollisions: 10, TxCarrier: 11, TxCompressed: 12,
+			}))
+		})
+
+		It("returns ESRCH for missing processes", func() {
+			ifaces := NetIfaceList{}
+			Expect(ifaces.GetForPid(4242)).To(MatchError(syscall.ESRCH))
+		})
+
+		It("groups processes by network namespace", func() {
+			netDev(procd+"/100/net/dev", "1000")
+			netDev(procd+"/101/net/dev", "1000")
+			netDev(procd+"/200/net/dev", "2000")
+			setupFile(procd+"/200/net/sockstat", "sockets: used 7\n")
+			nsLink("100", "net:[4026531833]")
+			nsLink("101", "net:[4026531833]")
+			nsLink("200", "net:[4026532001]")
+			nsLink("300", "bogus")
+
+			namespaces := NetNamespaceList{}
+			err := namespaces.GetForPids([]int{100, 200, 101, 300, 400})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(namespaces.List).To(HaveLen(2))
+
+			Expect(namespaces.List[0].Inode).To(Equal(uint64(4026531833)))
+			Expect(namespaces.List[0].Pids).To(Equal([]int{100, 101}))
+			Expect(namespaces.List[0].Ifaces.List[1].RxBytes).To(Equal(uint64(1000)))
+
+			Expect(namespaces.List[1].Inode).To(Equal(uint64(4026532001)))
+			Expect(namespaces.List[1].Pids).To(Equal([]int{200}))
+			Expect(namespaces.List[1].Ifaces.List[1].RxBytes).To(Equal(uint64(2000)))
+			Expect(namespaces.List[1].SockStat.SocketsUsed).To(Equal(uint64(7)))
+		})
+
+		It("skips namespaces whose counters cannot be read", func() {
+			netDev(procd+"/100/net/dev", "1000")
+			nsLink("100", "net:[4026531833]")
+			nsLink("200", "net:[4026532001]")
+
+			namespaces := NetNamespaceList{}
+			err := namespaces.GetForPids([]int{100, 200})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(namespaces.List).To(HaveLen(1))
+			Expect(namespaces.List[0].Pids).To(Equal([]int{100}))
+		})
+	})
+
+	Describe("ProcStat", func() {
+		const statLine = "4242 (my (odd) name) S 1 4242 4200 34816 4243 4194560 " +
+			"1500 20 3 1 250 120 10 5 20 -5 8 0 9876 123456789 2048 18446744073709551615 " +
+			"1 2 3 4 5 0 0 4096 16384 0 0 0 17 3 0 0 7 0 0 11 12 13 14 15 16 17 0\n"
+
+		BeforeEach(func() {
+			setupFile(procd+"/4242/stat", statLine)
+			setupFile(procd+"/4242/statm", "30000 512 256 1 0 200 0\n")
+		})
+
+		It("parses all fields with a single read", func() {
+			stat := ProcStat{}
+			err := stat.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(stat.Pid).To(Equal(4242))
+			Expect(stat.Name).To(Equal("my (odd) name"))
+			Expect(stat.State).To(Equal(RunState(RunStateSleep)))
+			Expect(stat.Ppid).To(Equal(1))
+			Expect(stat.Pgrp).To(Equal(4242))
+			Expect(stat.Session).To(Equal(4200))
+			Expect(stat.Tty).To(Equal(34816))
+			Expect(stat.Tpgid).To(Equal(4243))
+			Expect(stat.Flags).To(Equal(uint64(4194560)))
+			Expect(stat.MinFlt).To(Equal(uint64(1500)))
+			Expect(stat.CMinFlt).To(Equal(uint64(20)))
+			Expect(stat.MajFlt).To(Equal(uint64(3)))
+			Expect(stat.Utime).To(Equal(uint64(250)))
+			Expect(stat.Stime).To(Equal(uint64(120)))
+			Expect(stat.Cutime).To(Equal(int64(10)))
+			Expect(stat.Cstime).To(Equal(int64(5)))
+			Expect(stat.Priority).To(Equal(20))
+			Expect(stat.Nice).To(Equal(-5))
+			Expect(stat.NumThreads).To(Equal(8))
+			Expect(stat.StartTime).To(Equal(uint64(9876)))
+			Expect(stat.Vsize).To(Equal(uint64(123456789)))
+			Expect(stat.Rss).To(Equal(uint64(2048)))
+			Expect(stat.RssLim).To(Equal(MaxUint64))
+			Expect(stat.SigIgnore).To(Equal(uint64(4096)))
+			Expect(stat.SigCatch).To(Equal(uint64(16384)))
+			Expect(stat.ExitSignal).To(Equal(17))
+			Expect(stat.Processor).To(Equal(3))
+			Expect(stat.DelayAcctBlkioTicks).To(Equal(uint64(7)))
+			Expect(stat.StartData).To(Equal(uint64(11)))
+			Expect(stat.EnvEnd).To(Equal(uint64(17)))
+			Expect(stat.ExitCode).To(Equal(0))
+		})
+
+		It("accepts the shorter format of older kernels", func() {
+			procStatSetup(4243, map[string]string{"comm": "old", "state": "R"})
+
+			stat := ProcStat{}
+			err := stat.Get(4243)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stat.Name).To(Equal("old"))
+			Expect(stat.Rss).To(Equal(uint64(10)))
+			Expect(stat.Processor).To(Equal(0))
+		})
+
+		It("rejects malformed contents", func() {
+			setupFile(procd+"/4244/stat", "4244 truncated\n")
+
+			stat := ProcStat{}
+			Expect(stat.Get(4244)).ToNot(Succeed())
+		})
+
+		It("backs ProcState, ProcMem and ProcTime", func() {
+			state := ProcState{}
+			Expect(state.Get(4242)).To(Succeed())
+			Expect(state).To(Equal(ProcState{
+				Name:      "my (odd) name",
+				State:     RunStateSleep,
+				Ppid:      1,
+				Tty:       34816,
+				Priority:  20,
+				Nice:      -5,
+				Processor: 3,
+			}))
+
+			mem := ProcMem{}
+			Expect(mem.Get(4242)).To(Succeed())
+			Expect(mem.Resident).To(Equal(uint64(512 << 12)))
+			Expect(mem.MinorFaults).To(Equal(uint64(1500)))
+			Expect(mem.MajorFaults).To(Equal(uint64(3)))
+			Expect(mem.PageFaults).To(Equal(uint64(1503)))
+
+			ptime := ProcTime{}
+			Expect(ptime.Get(4242)).To(Succeed())
+			Expect(ptime.User).To(Equal(uint64(2500)))
+			Expect(ptime.Sys).To(Equal(uint64(1200)))
+			Expect(ptime.Total).To(Equal(uint64(3700)))
+		})
+	})
+
+	Describe("RunState", func() {
+		It("names every Linux state", func() {
+			names := map[RunState]string{
+				'R': "running",
+				'S': "sleeping",
+				'D': "disk sleep",
+				'T': "stopped",
+				't': "tracing stop",
+				'X': "dead",
+				'Z': "zombie",
+				'P': "parked",
+				'I': "idle",
+				'W': "waking",
+				'K': "wakekill",
+				'?': "unknown",
+			}
+			for state, name := range names {
+				Expect(state.String()).To(Equal(name))
+			}
+		})
+
+		It("keeps RunStateIdle compatible", func() {
+			Expect(RunState(RunStateIdle)).To(Equal(RunState('D')))
+		})
+
+		It("parses idle kernel threads", func() {
+			procStatSetup(77, map[string]string{
+				"comm": "kworker/0:1-events", "state": "I", "ppid": "2", "pgrp": "0", "session": "0",
+				"flags": "69238880", "utime": "0", "stime": "3", "starttime": "25", "vsize": "0", "rss": "0",
+				"rsslim": "18446744073709551615",
+			})
+
+			stat := ProcStat{}
+			Expect(stat.Get(77)).To(Succeed())
+			Expect(stat.State).To(Equal(RunState(RunStateTaskIdle)))
+			Expect(stat.IsKernelThread()).To(BeTrue())
+		})
+	})
+
+	Describe("ProcStat.IsKernelThread", func() {
+		It("detects PF_KTHREAD", func() {
+			stat := ProcStat{Pid: 10, Ppid: 1, Flags: ProcFlagKernelThread}
+			Expect(stat.IsKernelThread()).To(BeTrue())
+		})
+
+		It("trusts the flags inside pid namespaces", func() {
+			// A container's pid 2 is an ordinary process
+			Expect((&ProcStat{Pid: 2, Ppid: 1, Flags: 4194560}).IsKernelThread()).To(BeFalse())
+			Expect((&ProcStat{Pid: 12, Ppid: 2, Flags: 4194560}).IsKernelThread()).To(BeFalse())
+		})
+
+		It("detects kthreadd and its children without flags", func() {
+			Expect((&ProcStat{Pid: 2}).IsKernelThread()).To(BeTrue())
+			Expect((&ProcStat{Pid: 12, Ppid: 2}).IsKernelThread()).To(BeTrue())
+		})
+
+		It("does not flag userspace processes", func() {
+			stat := ProcStat{Pid: 1200, Ppid: 1, Flags: 4194560}
+			Expect(stat.IsKernelThread()).To(BeFalse())
+		})
+	})
+
+	Describe("ProcStatus", func() {
+		BeforeEach(func() {
+			setupFile(procd+"/4242/status", `Name:	my worker
+Umask:	0022
+State:	S (sleeping)
+Tgid:	4242
+Ngid:	0
+Pid:	4242
+PPid:	1
+TracerPid:	77
+Uid:	1000	1001	1002	1003
+Gid:	2000	2001	2002	2003
+FDSize:	256
+Groups:	4 24 1000 
+NStgid:	4242	12
+NSpid:	4242	12
+NSpgid:	4242	12
+NSsid:	4200	1
+VmPeak:	  404040 kB
+VmSize:	  400000 kB
+VmLck:	       0 kB
+VmPin:	       0 kB
+VmHWM:	   20480 kB
+VmRSS:	   10240 kB
+RssAnon:	    8192 kB
+RssFile:	    2000 kB
+RssShmem:	      48 kB
+VmData:	   30000 kB
+VmStk:	     132 kB
+VmExe:	      20 kB
+VmLib:	    1528 kB
+VmPTE:	      52 kB
+VmSwap:	    1024 kB
+Threads:	9
+SigQ:	0/23959
+SigPnd:	0000000000000000
+ShdPnd:	0000000000000100
+SigBlk:	0000000000010000
+SigIgn:	0000000000001000
+SigCgt:	0000000180004a02
+Cpus_allowed:	f0f
+Cpus_allowed_list:	0-3,8-11
+Mems_allowed_list:	0
+voluntary_ctxt_switches:	150
+nonvoluntary_ctxt_switches:	545
+`)
+		})
+
+		It("parses credentials, threads and memory", func() {
+			status := ProcStatus{}
+			err := status.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(status.Name).To(Equal("my worker"))
+			Expect(status.State).To(Equal(RunState(RunStateSleep)))
+			Expect(status.Tgid).To(Equal(4242))
+			Expect(status.Pid).To(Equal(4242))
+			Expect(status.Ppid).To(Equal(1))
+			Expect(status.TracerPid).To(Equal(77))
+
+			Expect([]int{status.Uid, status.Euid, status.Suid, status.FsUid}).To(Equal([]int{1000, 1001, 1002, 1003}))
+			Expect([]int{status.Gid, status.Egid, status.Sgid, status.FsGid}).To(Equal([]int{2000, 2001, 2002, 2003}))
+			Expect(status.Groups).To(Equal([]int{4, 24, 1000}))
+			Expect(status.NStgid).To(Equal([]int{4242, 12}))
+			Expect(status.NSpid).To(Equal([]int{4242, 12}))
+
+			Expect(status.FDSize).To(Equal(256))
+			Expect(status.Threads).To(Equal(9))
+
+			Expect(status.VmPeak).To(Equal(uint64(404040 * 1024)))
+			Expect(status.VmHWM).To(Equal(uint64(20480 * 1024)))
+			Expect(status.VmRSS).To(Equal(uint64(10240 * 1024)))
+			Expect(status.RssAnon).To(Equal(uint64(8192 * 1024)))
+			Expect(status.RssFile).To(Equal(uint64(2000 * 1024)))
+			Expect(status.RssShmem).To(Equal(uint64(48 * 1024)))
+			Expect(status.VmSwap).To(Equal(uint64(1024 * 1024)))
+
+			Expect(status.ShdPnd).To(Equal(uint64(0x100)))
+			Expect(status.SigBlk).To(Equal(uint64(0x10000)))
+			Expect(status.SigIgn).To(Equal(uint64(0x1000)))
+			Expect(status.SigCgt).To(Equal(uint64(0x180004a02)))
+
+			Expect(status.CpusAllowedList).To(Equal([]int{0, 1, 2, 3, 8, 9, 10, 11}))
+			Expect(status.MemsAllowedList).To(Equal([]int{0}))
+
+			Expect(status.VoluntaryCtxtSwitches).To(Equal(uint64(150)))
+			Expect(status.NonvoluntaryCtxtSwitches).To(Equal(uint64(545)))
+		})
+
+		It("returns ESRCH for missing processes", func() {
+			status := ProcStatus{}
+			Expect(status.Get(4243)).To(MatchError(syscall.ESRCH))
+		})
+
+		It("handles kernel threads without memory fields", func() {
+			setupFile(procd+"/2/status", "Name:\tkthreadd\nState:\tS (sleeping)\nPid:\t2\nPPid:\t0\nGroups:\t\nThreads:\t1\n")
+
+			status := ProcStatus{}
+			Expect(status.Get(2)).To(Succeed())
+			Expect(status.Name).To(Equal("kthreadd"))
+			Expect(status.Groups).To(BeEmpty())
+			Expect(status.VmRSS).To(Equal(uint64(0)))
+		})
+	})
+
+	Describe("ProcCred", func() {
+		BeforeEach(func() {
+			setupFile(procd+"/4242/status", "Name:\tworker\nUid:\t1000\t1001\t1001\t1001\nGid:\t2000\t2001\t2001\t2001\n")
+			setupFile(etcd+"/passwd", `root:x:0:0:root:/root:/bin/bash
+# comment:x:1001
+vcap:x:1001:2001::/home/vcap:/bin/bash
+shadow:x:1001:2001::/home/vcap:/bin/bash
+`)
+			setupFile(etcd+"/group", `root:x:0:
+vcap:x:2001:
+`)
+		})
+
+		It("resolves the effective user and group names", func() {
+			cred := ProcCred{}
+			err := cred.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cred).To(Equal(ProcCred{
+				Uid:   1000,
+				Gid:   2000,
+				Euid:  1001,
+				Egid:  2001,
+				User:  "vcap",
+				Group: "vcap",
+			}))
+		})
+
+		It("falls back to numeric ids", func() {
+			setupFile(procd+"/4243/status", "Name:\tworker\nUid:\t5\t5\t5\t5\nGid:\t6\t6\t6\t6\n")
+
+			cred := ProcCred{}
+			Expect(cred.Get(4243)).To(Succeed())
+			Expect(cred.User).To(Equal("5"))
+			Expect(cred.Group).To(Equal("6"))
+		})
+
+		It("reloads the names when the files change", func() {
+			cred := ProcCred{}
+			Expect(cred.Get(4242)).To(Succeed())
+			Expect(cred.User).To(Equal("vcap"))
+
+			Expect(os.Remove(etcd + "/passwd")).To(Succeed())
+			setupFile(etcd+"/passwd", "diego:x:1001:2001::/home/diego:/bin/sh\n")
+
+			Expect(cred.Get(4242)).To(Succeed())
+			Expect(cred.User).To(Equal("diego"))
+		})
+	})
+
+	Describe("ProcIO", func() {
+		procIOStart := func(pid, start string) {
+			p, _ := strconv.Atoi(pid) //nolint:errcheck
+			procStatSetup(p, map[string]string{"starttime": start})
+		}
+
+		procIO := func(pid, readBytes, writeBytes string) {
+			setupFile(procd+"/"+pid+"/io", `rchar: 323934931
+wchar: 323929600
+syscr: 632687
+syscw: 632675
+read_bytes: `+readBytes+`
+write_bytes: `+writeBytes+`
+cancelled_write_bytes: 42
+`)
+			procIOStart(pid, "500")
+		}
+
+		It("parses the I/O counters", func() {
+			procIO("4242", "4096", "8192")
+
+			pio := ProcIO{}
+			err := pio.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pio).To(Equal(ProcIO{
+				Rchar:               323934931,
+				Wchar:               323929600,
+				Syscr:               632687,
+				Syscw:               632675,
+				ReadBytes:           4096,
+				WriteBytes:          8192,
+				CancelledWriteBytes: 42,
+			}))
+		})
+
+		It("returns ESRCH for missing processes", func() {
+			pio := ProcIO{}
+			Expect(pio.Get(4243)).To(MatchError(syscall.ESRCH))
+		})
+
+		It("maps permission errors to PermissionError", func() {
+			err := procPermissionError(4242, "io", &os.PathError{Op: "open", Path: "io", Err: syscall.EACCES})
+
+			var permErr *PermissionError
+			Expect(errors.As(err, &permErr)).To(BeTrue())
+			Expect(permErr.Pid).To(Equal(4242))
+			Expect(permErr.Name).To(Equal("io"))
+			Expect(errors.Is(err, os.ErrPermission)).To(BeTrue())
+		})
+
+		Describe("ProcIOSampler", func() {
+			It("computes rates between samples", func() {
+				procIO("4242", "4096", "8192")
+				sampler := ProcIOSampler{}
+
+				_, ok, err := sampler.Sample(4242)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeFalse())
+
+				procIO("4242", "14096", "8192")
+				rate, ok, err := sampler.Sample(4242)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+				Expect(rate.ReadBytes).To(Equal(uint64(10000)))
+				Expect(rate.WriteBytes).To(Equal(uint64(0)))
+				Expect(rate.ReadBytesPerSec).To(BeNumerically(">", 0))
+			})
+
+			It("collects and sorts rates over an interval", func() {
+				procIO("100", "0", "0")
+				procIO("200", "0", "0")
+				sampler := ProcIOSampler{}
+
+				go func() {
+					defer GinkgoRecover()
+					time.Sleep(50 * time.Millisecond)
+					procIO("100", "100", "0")
+					procIO("200", "0", "5000")
+				}()
+
+				rates := sampler.Collect([]int{100, 200, 300}, 200*time.Millisecond)
+				Expect(rates).To(HaveLen(2))
+				Expect(rates[0].Pid).To(Equal(200))
+				Expect(rates[0].WriteBytes).To(Equal(uint64(5000)))
+				Expect(rates[1].Pid).To(Equal(100))
+				Expect(rates[1].ReadBytes).To(Equal(uint64(100)))
+			})
+
+			It("does not compare a reused pid with its predecessor", func() {
+				procIO("4242", "14096", "8192")
+				sampler := ProcIOSampler{}
+
+				_, ok, err := sampler.Sample(4242)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeFalse())
+
+				// Started within the same second as the previous process
+				procIO("4242", "4096", "8192")
+				procIOStart("4242", "550")
+				_, ok, err = sampler.Sample(4242)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeFalse())
+
+				procIO("4242", "5096", "8192")
+				procIOStart("4242", "550")
+				rate, ok, err := sampler.Sample(4242)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+				Expect(rate.ReadBytes).To(Equal(uint64(1000)))
+			})
+
+			It("keeps samples of pids collected by other callers", func() {
+				procIO("100", "0", "0")
+				procIO("200", "0", "0")
+				sampler := ProcIOSampler{}
+
+				_, _, err := sampler.Sample(200)
+				Expect(err).ToNot(HaveOccurred())
+
+				sampler.Collect([]int{100}, 10*time.Millisecond)
+
+				procIO("200", "300", "0")
+				rate, ok, err := sampler.Sample(200)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ok).To(BeTrue())
+				Expect(rate.ReadBytes).To(Equal(uint64(300)))
+			})
+		})
+	})
+
+	Describe("ProcThreadList", func() {
+		threadStat := func(tid, name, state, utime string) {
+			id, _ := strconv.Atoi(tid) //nolint:errcheck
+			setupFile(procd+"/4242/task/"+tid+"/stat", procStatLine(id, map[string]string{
+				"comm": name, "state": state, "pgrp": "4242", "session": "4200", "flags": "4194368",
+				"minflt": "10", "utime": utime, "stime": "5", "num_threads": "3", "starttime": "9876",
+				"vsize": "123456789", "rss": "2048", "exit_signal": "-1", "processor": "2", "cguest_time": "0",
+			}))
+		}
+
+		BeforeEach(func() {
+			procStatSetup(4242, map[string]string{"comm": "java", "starttime": "9876"})
+			threadStat("4242", "java", "S", "100")
+			threadStat("4250", "GC Thread#0", "R", "300")
+			threadStat("4251", "C2 CompilerThre", "S", "7")
+			setupFile(procd+"/4242/task/4250/status", "Name:\tGC Thread#0\nvoluntary_ctxt_switches:\t12\nnonvoluntary_ctxt_switches:\t34\n")
+		})
+
+		It("lists the threads of a process", func() {
+			threads := ProcThreadList{}
+			err := threads.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(threads.List).To(HaveLen(3))
+
+			gc := threads.List[1]
+			Expect(gc.Tid).To(Equal(4250))
+			Expect(gc.Name).To(Equal("GC Thread#0"))
+			Expect(gc.State).To(Equal(RunState(RunStateRun)))
+			Expect(gc.Processor).To(Equal(2))
+			Expect(gc.User).To(Equal(uint64(3000)))
+			Expect(gc.Sys).To(Equal(uint64(50)))
+			Expect(gc.VoluntaryCtxtSwitches).To(Equal(uint64(12)))
+			Expect(gc.NonvoluntaryCtxtSwitches).To(Equal(uint64(34)))
+		})
+
+		It("returns ESRCH for missing processes", func() {
+			threads := ProcThreadList{}
+			Expect(threads.Get(4243)).To(MatchError(syscall.ESRCH))
+		})
+
+		It("samples per thread CPU usage", func() {
+			sampler := ProcThreadCpuSampler{}
+
+			threads, err := sampler.Sample(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(threads).To(HaveLen(3))
+			for _, thread := range threads {
+				Expect(thread.Percent).To(Equal(0.0))
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			threadStat("4250", "GC Thread#0", "R", "301")
+			Expect(os.RemoveAll(procd + "/4242/task/4251")).To(Succeed())
+
+			threads, err = sampler.Sample(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(threads).To(HaveLen(2))
+			Expect(threads[0].Percent).To(Equal(0.0))
+			Expect(threads[1].Tid).To(Equal(4250))
+			Expect(threads[1].Percent).To(BeNumerically(">", 0))
+		})
+
+		It("starts over when the pid is reused", func() {
+			sampler := ProcThreadCpuSampler{}
+			_, err := sampler.Sample(4242)
+			Expect(err).ToNot(HaveOccurred())
+
+			time.Sleep(20 * time.Millisecond)
+			procStatSetup(4242, map[string]string{"comm": "java", "starttime": "9900"})
+			threadStat("4250", "GC Thread#0", "R", "900")
+
+			threads, err := sampler.Sample(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(threads).To(HaveLen(3))
+			for _, thread := range threads {
+				Expect(thread.Percent).To(Equal(0.0))
+			}
+		})
+
+		It("evicts exited processes", func() {
+			sampler := ProcThreadCpuSampler{}
+			_, err := sampler.Sample(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sampler.last).To(HaveLen(1))
+
+			sampler.Retain([]int{4243})
+			Expect(sampler.last).To(BeEmpty())
+		})
+	})
+
+	Describe("ProcEnv", func() {
+		It("parses the environment", func() {
+			setupFile(procd+"/4242/environ", "PATH=/usr/bin:/bin\x00HOME=/home/vcap\x00EMPTY=\x00OPTS=a=b\x00")
+
+			env := ProcEnv{}
+			err := env.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(env.List).To(Equal([]string{"PATH=/usr/bin:/bin", "HOME=/home/vcap", "EMPTY=", "OPTS=a=b"}))
+			Expect(env.Vars).To(Equal(map[string]string{
+				"PATH":  "/usr/bin:/bin",
+				"HOME":  "/home/vcap",
+				"EMPTY": "",
+				"OPTS":  "a=b",
+			}))
+		})
+
+		It("returns ESRCH for missing processes", func() {
+			env := ProcEnv{}
+			Expect(env.Get(4243)).To(MatchError(syscall.ESRCH))
+		})
+
+		Describe("AppTags", func() {
+			It("reads VCAP_APPLICATION", func() {
+				env := ProcEnv{Vars: map[string]string{
+					"VCAP_APPLICATION": `{"application_id":"app-guid","application_name":"dora",` +
+						`"space_id":"space-guid","space_name":"dev","organization_id":"org-guid",` +
+						`"organization_name":"acme","instance_id":"instance-guid","instance_index":2}`,
+				}}
+
+				tags, found := env.AppTags()
+				Expect(found).To(BeTrue())
+				Expect(tags).To(Equal(ProcAppTags{
+					AppGuid:       "app-guid",
+					AppName:       "dora",
+					SpaceGuid:     "space-guid",
+					SpaceName:     "dev",
+					OrgGuid:       "org-guid",
+					OrgName:       "acme",
+					InstanceGuid:  "instance-guid",
+					InstanceIndex: "2",
+				}))
+			})
+
+			It("falls back to the instance variables", func() {
+				env := ProcEnv{Vars: map[string]string{
+					"CF_INSTANCE_GUID": "instance-guid",
+					"INSTANCE_INDEX":   "3",
+				}}
+
+				tags, found := env.AppTags()
+				Expect(found).To(BeTrue())
+				Expect(tags.InstanceGuid).To(Equal("instance-guid"))
+				Expect(tags.InstanceIndex).To(Equal("3"))
+				Expect(tags.AppGuid).To(BeEmpty())
+			})
+
+			It("reports processes without tags", func() {
+				env := ProcEnv{Vars: map[string]string{"HOME": "/root"}}
+
+				_, found := env.AppTags()
+				Expect(found).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("ProcMemDetail", func() {
+		It("reads smaps_rollup", func() {
+			setupFile(procd+"/4242/smaps_rollup", `55b0a6da6000-7ffc4e126000 ---p 00000000 00:00 0                          [rollup]
+Rss:                1400 kB
+Pss:                 466 kB
+Pss_Dirty:           100 kB
+Pss_Anon:            100 kB
+Pss_File:            366 kB
+Pss_Shmem:             0 kB
+Shared_Clean:       1260 kB
+Shared_Dirty:          0 kB
+Private_Clean:        40 kB
+Private_Dirty:       100 kB
+Referenced:         1400 kB
+Anonymous:           100 kB
+AnonHugePages:      2048 kB
+Swap:                 12 kB
+SwapPss:               6 kB
+Locked:                4 kB
+`)
+
+			detail := ProcMemDetail{}
+			err := detail.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(detail).To(Equal(ProcMemDetail{
+				Rss:           1400 * 1024,
+				Pss:           466 * 1024,
+				PssAnon:       100 * 1024,
+				PssFile:       366 * 1024,
+				SharedClean:   1260 * 1024,
+				PrivateClean:  40 * 1024,
+				PrivateDirty:  100 * 1024,
+				Uss:           140 * 1024,
+				Swap:          12 * 1024,
+				SwapPss:       6 * 1024,
+				AnonHugePages: 2048 * 1024,
+				Locked:        4 * 1024,
+			}))
+		})
+
+		It("sums smaps on older kernels", func() {
+			setupFile(procd+"/4242/smaps", `55881cced000-55881ccef000 r--p 00000000 fe:00 681885                     /usr/bin/head
+Size:                  8 kB
+Rss:                   8 kB
+Pss:                   4 kB
+Shared_Clean:          8 kB
+Private_Clean:         0 kB
+Private_Dirty:         0 kB
+Swap:                  0 kB
+VmFlags: rd mr mw me
+7f0000000000-7f0000021000 rw-p 00000000 00:00 0                          [heap]
+Size:                132 kB
+Rss:                  64 kB
+Pss:                  64 kB
+Shared_Clean:          0 kB
+Private_Clean:         0 kB
+Private_Dirty:        64 kB
+Swap:                 16 kB
+VmFlags: rd wr mr mw me ac
+`)
+
+			detail := ProcMemDetail{}
+			err := detail.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(detail.Rss).To(Equal(uint64(72 * 1024)))
+			Expect(detail.Pss).To(Equal(uint64(68 * 1024)))
+			Expect(detail.Uss).To(Equal(uint64(64 * 1024)))
+			Expect(detail.Swap).To(Equal(uint64(16 * 1024)))
+		})
+
+		It("returns ESRCH for missing processes", func() {
+			detail := ProcMemDetail{}
+			Expect(detail.Get(4243)).To(MatchError(syscall.ESRCH))
+		})
+	})
+
+	Describe("ProcMapList", func() {
+		It("parses the memory maps", func() {
+			setupFile(procd+"/4242/maps", `55881cced000-55881ccef000 r--p 00000000 fe:00 681885                     /usr/bin/head
+7f0000000000-7f0000021000 rw-p 00000000 00:00 0                          [heap]
+7f0000100000-7f0000200000 rw-s 00001000 00:05 4242                       /dev/shm/with space (deleted)
+7f0000300000-7f0000301000 ---p 00000000 00:00 0 
+`)
+
+			maps := ProcMapList{}
+			err := maps.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(maps.List).To(HaveLen(4))
+
+			Expect(maps.List[0]).To(Equal(ProcMap{
+				Start: 0x55881cced000,
+				End:   0x55881ccef000,
+				Perms: "r--p",
+				Dev:   "fe:00",
+				Inode: 681885,
+				Path:  "/usr/bin/head",
+			}))
+			Expect(maps.List[0].Size()).To(Equal(uint64(8192)))
+			Expect(maps.List[1].Path).To(Equal("[heap]"))
+			Expect(maps.List[2].Offset).To(Equal(uint64(0x1000)))
+			Expect(maps.List[2].Path).To(Equal("/dev/shm/with space (deleted)"))
+			Expect(maps.List[3].Path).To(Equal(""))
+		})
+
+		It("joins the smaps usage", func() {
+			setupFile(procd+"/4242/smaps", `55881cced000-55881ccef000 r--p 00000000 fe:00 681885                     /usr/bin/head
+Size:                  8 kB
+Rss:                   8 kB
+Pss:                   4 kB
+Swap:                  0 kB
+VmFlags: rd mr mw me
+7f0000000000-7f0000021000 rw-p 00000000 00:00 0                          [heap]
+Size:                132 kB
+Rss:                  64 kB
+Pss:                  64 kB
+Swap:                 16 kB
+VmFlags: rd wr mr mw me ac
+`)
+
+			maps := ProcMapList{}
+			err := maps.GetWithUsage(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(maps.List).To(HaveLen(2))
+			Expect(maps.List[0].Rss).To(Equal(uint64(8 * 1024)))
+			Expect(maps.List[0].Pss).To(Equal(uint64(4 * 1024)))
+			Expect(maps.List[1].Path).To(Equal("[heap]"))
+			Expect(maps.List[1].Swap).To(Equal(uint64(16 * 1024)))
+		})
+
+		It("returns ESRCH for missing processes", func() {
+			maps := ProcMapList{}
+			Expect(maps.Get(4243)).To(MatchError(syscall.ESRCH))
+		})
+	})
+
+	Describe("ProcFdList", func() {
+		fdLink := func(fd, target string) {
+			_ = os.MkdirAll(procd+"/4242/fd", 0755) //nolint:errcheck
+			Expect(os.Symlink(target, procd+"/4242/fd/"+fd)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			setupFile(procd+"/data/log.txt", "some data")
+
+			fdLink("0", "/dev/null")
+			fdLink("1", "pipe:[38709]")
+			fdLink("2", procd+"/data/log.txt")
+			fdLink("3", "socket:[38944]")
+			fdLink("4", "anon_inode:[eventfd]")
+			fdLink("5", "anon_inode:inotify")
+			fdLink("10", procd+"/data")
+
+			setupFile(procd+"/4242/fdinfo/2", "pos:\t9\nflags:\t02102001\nmnt_id:\t16\n")
+			setupFile(procd+"/4242/limits", `Limit                     Soft Limit           Hard Limit           Units
+Max processes             63704                63704                processes
+Max open files            1024                 524288               files
+`)
+		})
+
+		It("classifies the open descriptors", func() {
+			fds := ProcFdList{}
+			err := fds.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fds.List).To(HaveLen(7))
+
+			Expect(fds.List[0].Type).To(Equal(ProcFdDevice))
+			Expect(fds.List[0].Target).To(Equal("/dev/null"))
+
+			Expect(fds.List[1].Type).To(Equal(ProcFdPipe))
+			Expect(fds.List[1].Inode).To(Equal(uint64(38709)))
+
+			Expect(fds.List[2]).To(Equal(ProcFd{
+				Fd:     2,
+				Type:   ProcFdFile,
+				Target: procd + "/data/log.txt",
+				Pos:    9,
+				Flags:  02102001,
+			}))
+
+			Expect(fds.List[3].Type).To(Equal(ProcFdSocket))
+			Expect(fds.List[3].Inode).To(Equal(uint64(38944)))
+
+			Expect(fds.List[4].Type).To(Equal(ProcFdAnonInode))
+			Expect(fds.List[4].AnonInode).To(Equal("eventfd"))
+			Expect(fds.List[5].AnonInode).To(Equal("inotify"))
+
+			Expect(fds.List[6].Fd).To(Equal(10))
+			Expect(fds.List[6].Type).To(Equal(ProcFdDir))
+			Expect(fds.List[6].Type.String()).To(Equal("DIR"))
+		})
+
+		It("counts descriptors against the NOFILE limit", func() {
+			count := ProcFdCount{}
+			err := count.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(ProcFdCount{Count: 7, SoftLimit: 1024, HardLimit: 524288}))
+			Expect(count.Usage()).To(BeNumerically("~", 7.0/1024, 1e-9))
+		})
+
+		It("returns ESRCH for missing processes", func() {
+			fds := ProcFdList{}
+			Expect(fds.Get(4243)).To(MatchError(syscall.ESRCH))
+
+			count := ProcFdCount{}
+			Expect(count.Get(4243)).To(MatchError(syscall.ESRCH))
+		})
+	})
+
+	Describe("ProcLimits", func() {
+		BeforeEach(func() {
+			setupFile(procd+"/4242/limits", `Limit                     Soft Limit           Hard Limit           Units     
+Max cpu time              unlimited            unlimited            seconds   
+Max file size             unlimited            unlimited            bytes     
+Max data size             unlimited            unlimited            bytes     
+Max stack size            8388608              unlimited            bytes     
+Max core file size        0                    unlimited            bytes     
+Max resident set          unlimited            unlimited            bytes     
+Max processes             100                  23959                processes 
+Max open files            4                    20000                files     
+Max locked memory         8388608              8388608              bytes     
+Max address space         1073741824           unlimited            bytes     
+Max file locks            unlimited            unlimited            locks     
+Max pending signals       23959                23959                signals   
+Max msgqueue size         819200               819200               bytes     
+Max nice priority         0                    0                    
+Max realtime priority     0                    0                    
+Max realtime timeout      unlimited            unlimited            us        
+`)
+		})
+
+		It("parses soft and hard limits", func() {
+			limits := ProcLimits{}
+			err := limits.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(limits.Cpu).To(Equal(Rlimit{Soft: RlimitInfinity, Hard: RlimitInfinity}))
+			Expect(limits.Cpu.SoftUnlimited()).To(BeTrue())
+			Expect(limits.Stack).To(Equal(Rlimit{Soft: 8388608, Hard: RlimitInfinity}))
+			Expect(limits.Stack.SoftUnlimited()).To(BeFalse())
+			Expect(limits.Stack.HardUnlimited()).To(BeTrue())
+			Expect(limits.Core).To(Equal(Rlimit{Soft: 0, Hard: RlimitInfinity}))
+			Expect(limits.Processes).To(Equal(Rlimit{Soft: 100, Hard: 23959}))
+			Expect(limits.OpenFiles).To(Equal(Rlimit{Soft: 4, Hard: 20000}))
+			Expect(limits.LockedMemory).To(Equal(Rlimit{Soft: 8388608, Hard: 8388608}))
+			Expect(limits.AddressSpace).To(Equal(Rlimit{Soft: 1073741824, Hard: RlimitInfinity}))
+			Expect(limits.MsgQueue).To(Equal(Rlimit{Soft: 819200, Hard: 819200}))
+			Expect(limits.Nice).To(Equal(Rlimit{}))
+			Expect(limits.RtTimeout.HardUnlimited()).To(BeTrue())
+		})
+
+		It("compares the limits with live usage", func() {
+			setupFile(procd+"/4242/status", "Name:\tworker\nVmSize:\t  524288 kB\nThreads:\t25\n")
+			_ = os.MkdirAll(procd+"/4242/fd", 0755) //nolint:errcheck
+			for _, fd := range []string{"0", "1", "2"} {
+				Expect(os.Symlink("/dev/null", procd+"/4242/fd/"+fd)).To(Succeed())
+			}
+
+			usage := ProcLimitsUsage{}
+			err := usage.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(usage.OpenFiles.Used).To(Equal(uint64(3)))
+			Expect(usage.OpenFiles.Usage()).To(Equal(0.75))
+			Expect(usage.Threads.Used).To(Equal(uint64(25)))
+			Expect(usage.Threads.Usage()).To(Equal(0.25))
+			Expect(usage.AddressSpace.Used).To(Equal(uint64(512 * 1024 * 1024)))
+			Expect(usage.AddressSpace.Usage()).To(Equal(0.5))
+		})
+
+		It("reports no usage against unlimited limits", func() {
+			usage := ProcLimitUsage{Used: 10, Limit: Rlimit{Soft: RlimitInfinity, Hard: RlimitInfinity}}
+			Expect(usage.Usage()).To(Equal(0.0))
+		})
+
+		It("returns ESRCH for missing processes", func() {
+			limits := ProcLimits{}
+			Expect(limits.Get(4243)).To(MatchError(syscall.ESRCH))
+		})
+	})
+
+	Describe("ProcCgroups", func() {
+		It("parses v1 controllers and the v2 unified path", func() {
+			setupFile(procd+"/4242/cgroup", `9:name=systemd:/
+4:memory:/garden/instance-guid
+2:cpu,cpuacct:/garden/instance-guid
+0::/system.slice/garden.service:extra
+`)
+
+			cgroups := ProcCgroups{}
+			err := cgroups.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cgroups.List).To(Equal([]ProcCgroup{
+				{HierarchyId: 9, Controllers: []string{"name=systemd"}, Path: "/"},
+				{HierarchyId: 4, Controllers: []string{"memory"}, Path: "/garden/instance-guid"},
+				{HierarchyId: 2, Controllers: []string{"cpu", "cpuacct"}, Path: "/garden/instance-guid"},
+				{HierarchyId: 0, Path: "/system.slice/garden.service:extra"},
+			}))
+
+			path, found := cgroups.Controller("cpuacct")
+			Expect(found).To(BeTrue())
+			Expect(path).To(Equal("/garden/instance-guid"))
+
+			_, found = cgroups.Controller("pids")
+			Expect(found).To(BeFalse())
+
+			path, found = cgroups.Unified()
+			Expect(found).To(BeTrue())
+			Expect(path).To(Equal("/system.slice/garden.service:extra"))
+		})
+
+		It("returns ESRCH for missing processes", func() {
+			cgroups := ProcCgroups{}
+			Expect(cgroups.Get(4243)).To(MatchError(syscall.ESRCH))
+		})
+	})
+
+	Describe("ProcNamespaces", func() {
+		It("reads the namespace inodes", func() {
+			_ = os.MkdirAll(procd+"/4242/ns", 0755) //nolint:errcheck
+			links := map[string]string{
+				"mnt":    "mnt:[4026531832]",
+				"net":    "net:[4026531833]",
+				"pid":    "pid:[4026531836]",
+				"uts":    "uts:[4026531838]",
+				"ipc":    "ipc:[4026531839]",
+				"user":   "user:[4026531837]",
+				"cgroup": "cgroup:[4026531835]",
+			}
+			for name, target := range links {
+				Expect(os.Symlink(target, procd+"/4242/ns/"+name)).To(Succeed())
+			}
+
+			namespaces := ProcNamespaces{}
+			err := namespaces.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(namespaces).To(Equal(ProcNamespaces{
+				Mnt:    4026531832,
+				Net:    4026531833,
+				Pid:    4026531836,
+				Uts:    4026531838,
+				Ipc:    4026531839,
+				User:   4026531837,
+				Cgroup: 4026531835,
+			}))
+		})
+
+		It("returns ESRCH for missing processes", func() {
+			namespaces := ProcNamespaces{}
+			Expect(namespaces.Get(4243)).To(MatchError(syscall.ESRCH))
+		})
+	})
+
+	Describe("ProcTree", func() {
+		procSetup := func(pid, ppid int, name string, utime, rssPages, threads int) {
+			dir := procd + "/" + strconv.Itoa(pid)
+			procStatSetup(pid, map[string]string{
+				"comm": name, "ppid": strconv.Itoa(ppid), "utime": strconv.Itoa(utime), "stime": "0",
+				"num_threads": strconv.Itoa(threads), "rss": strconv.Itoa(rssPages),
+			})
+			setupFile(dir+"/statm", fmt.Sprintf("1000 %d 0 1 0 200 0\n", rssPages))
+			setupFile(dir+"/status", fmt.Sprintf("Name:\t%s\nThreads:\t%d\n", name, threads))
+			setupFile(dir+"/smaps_rollup", fmt.Sprintf("Rss: %d kB\nPss: %d kB\n", rssPages*4, rssPages*2))
+		}
+
+		BeforeEach(func() {
+			procSetup(1, 0, "init", 10, 100, 1)
+			procSetup(2, 0, "kthreadd", 0, 0, 1)
+			procSetup(100, 1, "bash", 20, 200, 1)
+			procSetup(101, 100, "make", 30, 300, 2)
+			procSetup(102, 100, "sleep", 40, 400, 3)
+			procSetup(103, 101, "cc", 50, 500, 4)
+		})
+
+		It("links parents and children", func() {
+			tree := ProcTree{}
+			Expect(tree.Get()).To(Succeed())
+
+			Expect(tree.Pids()).To(Equal([]int{1, 2, 100, 101, 102, 103}))
+			Expect(tree.Roots()).To(Equal([]int{1, 2}))
+			Expect(tree.Children(100)).To(Equal([]int{101, 102}))
+			Expect(tree.Children(102)).To(BeEmpty())
+			Expect(tree.Ancestors(103)).To(Equal([]int{101, 100, 1}))
+			Expect(tree.Descendants(100)).To(Equal([]int{101, 103, 102}))
+			Expect(tree.Depth(1)).To(Equal(0))
+			Expect(tree.Depth(103)).To(Equal(3))
+
+			ppid, found := tree.Parent(101)
+			Expect(found).To(BeTrue())
+			Expect(ppid).To(Equal(100))
+
+			_, found = tree.Parent(1)
+			Expect(found).To(BeFalse())
+
+			state, found := tree.State(103)
+			Expect(found).To(BeTrue())
+			Expect(state.Name).To(Equal("cc"))
+		})
+
+		It("sums usage over a subtree", func() {
+			tree := ProcTree{}
+			Expect(tree.Get()).To(Succeed())
+
+			usage, err := tree.Usage(101)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(usage).To(Equal(ProcTreeUsage{
+				Procs:   2,
+				Rss:     800 << 12,
+				Pss:     800 * 2 * 1024,
+				CpuTime: 80 * (1000 / system.ticks),
+				Threads: 6,
+			}))
+		})
+
+		It("reads rss, cpu time and threads from stat alone", func() {
+			tree := ProcTree{}
+			Expect(tree.Get()).To(Succeed())
+			for _, pid := range []string{"101", "103"} {
+				Expect(os.Remove(procd + "/" + pid + "/statm")).To(Succeed())
+				Expect(os.Remove(procd + "/" + pid + "/status")).To(Succeed())
+			}
+
+			usage, err := tree.Usage(101)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(usage.Procs).To(Equal(2))
+			Expect(usage.Rss).To(Equal(uint64(800 << 12)))
+			Expect(usage.CpuTime).To(Equal(uint64(80 * (1000 / system.ticks))))
+			Expect(usage.Threads).To(Equal(uint64(6)))
+		})
+
+		It("records the ids of the processes", func() {
+			tree := ProcTree{}
+			Expect(tree.Get()).To(Succeed())
+
+			id, found := tree.ID(103)
+			Expect(found).To(BeTrue())
+			Expect(id).To(Equal(ProcID{Pid: 103, StartTime: 100}))
+
+			_, found = tree.ID(104)
+			Expect(found).To(BeFalse())
+		})
+
+		It("does not signal pids reused since the tree was built", func() {
+			tree := ProcTree{}
+			Expect(tree.Get()).To(Succeed())
+			procStatSetup(103, map[string]string{
+				"comm": "cc", "ppid": "101", "utime": "50", "stime": "0", "num_threads": "4",
+				"starttime": "200", "rss": "500",
+			})
+
+			err := ProcControl{}.SignalTree(&tree, 103, syscall.SIGKILL)
+			var nerr *NoSuchProcessError
+			Expect(errors.As(err, &nerr)).To(BeTrue())
+			Expect(nerr.Pid).To(Equal(103))
+		})
+
+		It("skips processes which exited after the tree was built", func() {
+			tree := ProcTree{}
+			Expect(tree.Get()).To(Succeed())
+			Expect(os.RemoveAll(procd + "/103")).To(Succeed())
+
+			usage, err := tree.Usage(100)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(usage.Procs).To(Equal(3))
+			Expect(usage.Threads).To(Equal(uint64(6)))
+
+			_, err = tree.Usage(103)
+			Expect(err).To(MatchError(syscall.ESRCH))
+		})
+	})
+
+	Describe("ProcQuery", func() {
+		var btime uint64
+
+		procSetup := func(pid, ppid, session int, name string, state byte, start uint64, uid int, cmdline, cgroup string) {
+			dir := procd + "/" + strconv.Itoa(pid)
+			procStatSetup(pid, map[string]string{
+				"comm": name, "state": string(state), "ppid": strconv.Itoa(ppid), "pgrp": strconv.Itoa(pid),
+				"session": strconv.Itoa(session), "starttime": strconv.FormatUint(start, 10),
+			})
+			setupFile(dir+"/status", fmt.Sprintf("Name:\t%s\nUid:\t%d\t%d\t%d\t%d\nGid:\t0\t0\t0\t0\n", name, uid, uid, uid, uid))
+			setupFile(dir+"/cmdline", cmdline)
+			setupFile(dir+"/cgroup", "0::"+cgroup+"\n")
+			Expect(os.Symlink("/usr/bin/"+name, dir+"/exe")).To(Succeed())
+			Expect(os.Symlink("/", dir+"/cwd")).To(Succeed())
+			Expect(os.Symlink("/", dir+"/root")).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			btime = system.btime
+			system.btime = uint64(time.Now().Unix()) - 86400
+
+			setupFile(etcd+"/passwd", "root:x:0:0::/root:/bin/sh\nvcap:x:1000:1000::/home/vcap:/bin/sh\n")
+			procSetup(1, 0, 1, "init", 'S', 0, 0, "/sbin/init\x00", "/init.scope")
+			procSetup(100, 1, 100, "nginx", 'S', 100, 1000, "nginx: master process\x00", "/system.slice/nginx.service")
+			procSetup(101, 100, 100, "nginx", 'R', 86400*100, 1000, "nginx: worker process\x00", "/system.slice/nginx.service/worker")
+			procSetup(200, 1, 200, "sshd", 'S', 100, 0, "/usr/sbin/sshd\x00-D\x00", "/system.slice/ssh.service")
+		})
+
+		AfterEach(func() {
+			system.btime = btime
+		})
+
+		find := func(q *ProcQuery) []int {
+			pids, err := q.Find()
+			Expect(err).ToNot(HaveOccurred())
+			return pids
+		}
+
+		It("matches every process without predicates", func() {
+			Expect(find(&ProcQuery{})).To(Equal([]int{1, 100, 101, 200}))
+		})
+
+		It("combines predicates", func() {
+			q := ProcQuery{}
+			Expect(find(q.Name(regexp.MustCompile("^ng")))).To(Equal([]int{100, 101}))
+			Expect(find(q.Cmdline(regexp.MustCompile("worker")))).To(Equal([]int{101}))
+
+			Expect(find((&ProcQuery{}).Cmdline(regexp.MustCompile(`sshd -D$`)))).To(Equal([]int{200}))
+			Expect(find((&ProcQuery{}).Exe("/usr/bin/sshd"))).To(Equal([]int{200}))
+			Expect(find((&ProcQuery{}).Uid(1000))).To(Equal([]int{100, 101}))
+			Expect(find((&ProcQuery{}).User("root"))).To(Equal([]int{1, 200}))
+			Expect(find((&ProcQuery{}).Ppid(100))).To(Equal([]int{101}))
+			Expect(find((&ProcQuery{}).Session(100))).To(Equal([]int{100, 101}))
+			Expect(find((&ProcQuery{}).State(RunStateRun, RunStateZombie))).To(Equal([]int{101}))
+			Expect(find((&ProcQuery{}).MinAge(time.Hour))).To(Equal([]int{1, 100, 200}))
+		})
+
+		It("matches cgroup paths on path boundaries", func() {
+			Expect(find((&ProcQuery{}).CgroupPath("/system.slice/nginx.service"))).To(Equal([]int{100, 101}))
+			Expect(find((&ProcQuery{}).CgroupPath("/system.slice/"))).To(Equal([]int{100, 101, 200}))
+			Expect(find((&ProcQuery{}).CgroupPath("/system.slice/nginx"))).To(BeEmpty())
+		})
+
+		It("only reads the files needed to rule a process out", func() {
+			// A cmdline which can not be read fails the query once reached
+			Expect(os.Remove(procd + "/200/cmdline")).To(Succeed())
+			Expect(os.Mkdir(procd+"/200/cmdline", 0755)).To(Succeed())
+
+			q := ProcQuery{}
+			q.Cmdline(regexp.MustCompile("nginx")).Name(regexp.MustCompile("nginx"))
+			Expect(find(&q)).To(Equal([]int{100, 101}))
+
+			_, err := (&ProcQuery{}).Cmdline(regexp.MustCompile("nginx")).Find()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("skips processes which vanished", func() {
+			Expect(os.RemoveAll(procd + "/100/cgroup")).To(Succeed())
+			Expect(os.MkdirAll(procd+"/4242", 0755)).To(Succeed())
+
+			Expect(find((&ProcQuery{}).CgroupPath("/system.slice"))).To(Equal([]int{101, 200}))
+
+			matched, err := (&ProcQuery{}).Name(regexp.MustCompile(".")).Match(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matched).To(BeFalse())
+		})
+	})
+
+	Describe("ProcControl errors", func() {
+		It("maps ESRCH and EPERM to typed errors", func() {
+			err := procControlError(4242, "renice", syscall.EPERM)
+			var perr *PermissionError
+			Expect(errors.As(err, &perr)).To(BeTrue())
+			Expect(perr.Op).To(Equal("renice"))
+			Expect(err).To(MatchError("gosigar: permission denied to renice pid 4242"))
+			Expect(err).To(MatchError(syscall.EPERM))
+
+			err = procControlError(4242, "signal", syscall.ESRCH)
+			var nerr *NoSuchProcessError
+			Expect(errors.As(err, &nerr)).To(BeTrue())
+			Expect(err).To(MatchError("gosigar: signal pid 4242: no such process"))
+			Expect(err).To(MatchError(syscall.ESRCH))
+
+			Expect(procControlError(4242, "signal", syscall.EINVAL)).To(Equal(syscall.EINVAL))
+			Expect(procControlError(4242, "signal", nil)).To(Succeed())
+		})
+	})
+
+	Describe("ProcSched", func() {
+		var pid int
+
+		BeforeEach(func() {
+			// The affinity and I/O priority are read with syscalls, so
+			// the fixtures are set up for this process.
+			pid = os.Getpid()
+			dir := procd + "/" + strconv.Itoa(pid)
+			procStatSetup(pid, map[string]string{
+				"priority": "-51", "nice": "-5", "rsslim": "18446744073709551615",
+				"startcode": "1", "endcode": "2", "startstack": "3", "kstkesp": "4", "kstkeip": "5",
+				"exit_signal": "17", "processor": "3", "rt_priority": "50", "policy": "1", "cguest_time": "0",
+			})
+			setupFile(dir+"/schedstat", "123456789 5000000 42\n")
+		})
+
+		It("reads the policy, priorities, affinity and schedstat times", func() {
+			sched := ProcSched{}
+			err := sched.Get(pid)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(sched.Policy).To(Equal(SchedFifo))
+			Expect(sched.Policy.String()).To(Equal("fifo"))
+			Expect(sched.RtPriority).To(Equal(50))
+			Expect(sched.Nice).To(Equal(-5))
+			Expect(sched.RunTime).To(Equal(123456789 * time.Nanosecond))
+			Expect(sched.WaitTime).To(Equal(5 * time.Millisecond))
+			Expect(sched.Timeslices).To(Equal(uint64(42)))
+
+			Expect(sched.Affinity).ToNot(BeEmpty())
+			Expect(sched.IOPrioLevel).To(BeNumerically("<=", 7))
+		})
+
+		It("leaves the times zero without schedstats", func() {
+			Expect(os.Remove(procd + "/" + strconv.Itoa(pid) + "/schedstat")).To(Succeed())
+
+			sched := ProcSched{RunTime: time.Second}
+			Expect(sched.Get(pid)).To(Succeed())
+			Expect(sched.RunTime).To(BeZero())
+			Expect(sched.Timeslices).To(BeZero())
+		})
+
+		It("returns ESRCH for missing processes", func() {
+			sched := ProcSched{}
+			Expect(sched.Get(4243)).To(MatchError(syscall.ESRCH))
+		})
+	})
+
+	Describe("ProcCpuSampler", func() {
+		procStat := func(pid, utime, start string) {
+			p, _ := strconv.Atoi(pid) //nolint:errcheck
+			procStatSetup(p, map[string]string{"utime": utime, "stime": "0", "starttime": start})
+		}
+
+		It("returns no data for the first sample without sleeping", func() {
+			procStat("4242", "100", "500")
+			sampler := ProcCpuSampler{}
+
+			begin := time.Now()
+			cpu, ok, err := sampler.Sample(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+			Expect(cpu.Percent).To(Equal(0.0))
+			Expect(cpu.User).To(Equal(uint64(1000)))
+			Expect(time.Since(begin)).To(BeNumerically("<", 50*time.Millisecond))
+		})
+
+		It("computes the usage between samples", func() {
+			procStat("4242", "100", "500")
+			sampler := ProcCpuSampler{}
+			_, _, err := sampler.Sample(4242)
+			Expect(err).ToNot(HaveOccurred())
+
+			time.Sleep(20 * time.Millisecond)
+			procStat("4242", "101", "500")
+
+			cpu, ok, err := sampler.Sample(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(cpu.Percent).To(BeNumerically(">", 0))
+		})
+
+		It("starts over when the pid is reused", func() {
+			procStat("4242", "500", "500")
+			sampler := ProcCpuSampler{}
+			_, _, err := sampler.Sample(4242)
+			Expect(err).ToNot(HaveOccurred())
+
+			procStat("4242", "1", "900")
+			cpu, ok, err := sampler.Sample(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+			Expect(cpu.Percent).To(Equal(0.0))
+		})
+
+		It("starts over when the pid is reused within the same second", func() {
+			procStat("4242", "500", "500")
+			sampler := ProcCpuSampler{}
+			_, _, err := sampler.Sample(4242)
+			Expect(err).ToNot(HaveOccurred())
+
+			procStat("4242", "501", "550")
+			cpu, ok, err := sampler.Sample(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+			Expect(cpu.Percent).To(Equal(0.0))
+		})
+
+		It("evicts exited processes", func() {
+			procStat("100", "1", "500")
+			procStat("200", "1", "500")
+			sampler := ProcCpuSampler{}
+			for _, pid := range []int{100, 200} {
+				_, _, err := sampler.Sample(pid)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(os.RemoveAll(procd + "/100")).To(Succeed())
+			_, _, err := sampler.Sample(100)
+			Expect(err).To(MatchError(syscall.ESRCH))
+			Expect(sampler.last).To(HaveLen(1))
+
+			sampler.Retain([]int{300})
+			Expect(sampler.last).To(BeEmpty())
+		})
+
+		It("is safe for concurrent use", func() {
+			sampler := ProcCpuSampler{}
+			done := make(chan struct{})
+			for i := 0; i < 8; i++ {
+				pid := 4250 + i
+				procStat(strconv.Itoa(pid), "1", "500")
+				go func() {
+					defer GinkgoRecover()
+					for j := 0; j < 20; j++ {
+						_, _, err := sampler.Sample(pid)
+						Expect(err).ToNot(HaveOccurred())
+					}
+					sampler.Retain([]int{pid})
+					done <- struct{}{}
+				}()
+			}
+			for i := 0; i < 8; i++ {
+				<-done
+			}
+		})
+	})
+
+	Describe("TopProcesses", func() {
+		procSetup := func(pid int, name string, utime, rssPages int, swapKb int, fds int) {
+			dir := procd + "/" + strconv.Itoa(pid)
+			procStatSetup(pid, map[string]string{
+				"comm": name, "utime": strconv.Itoa(utime), "stime": "0", "rss": strconv.Itoa(rssPages),
+			})
+			setupFile(dir+"/statm", fmt.Sprintf("1000 %d 0 1 0 200 0\n", rssPages))
+			setupFile(dir+"/status", fmt.Sprintf("Name:\t%s\nVmSwap:\t%d kB\n", name, swapKb))
+			setupFile(dir+"/limits", "Limit                     Soft Limit           Hard Limit           Units     \n"+
+				"Max open files            1024                 4096                 files     \n")
+			for fd := 0; fd < fds; fd++ {
+				setupFile(fmt.Sprintf("%s/fd/%d", dir, fd), "")
+			}
+		}
+
+		BeforeEach(func() {
+			procSetup(100, "small", 10, 100, 0, 3)
+			procSetup(200, "big", 20, 900, 512, 1)
+			procSetup(300, "medium", 30, 500, 2048, 7)
+		})
+
+		It("ranks by memory, swap and fd count", func() {
+			top := TopProcesses{Metric: TopByRss, N: 2}
+			Expect(top.Get()).To(Succeed())
+			Expect(top.List).To(Equal([]TopProcess{
+				{Pid: 200, Name: "big", Value: 900 << 12},
+				{Pid: 300, Name: "medium", Value: 500 << 12},
+			}))
+
+			top = TopProcesses{Metric: TopBySwap}
+			Expect(top.Get()).To(Succeed())
+			Expect(top.List).To(HaveLen(3))
+			Expect(top.List[0].Pid).To(Equal(300))
+			Expect(top.List[0].Value).To(Equal(2048.0 * 1024))
+
+			top = TopProcesses{Metric: TopByFds, N: 1}
+			Expect(top.Get()).To(Succeed())
+			Expect(top.List).To(Equal([]TopProcess{{Pid: 300, Name: "medium", Value: 7}}))
+		})
+
+		It("ranks rate metrics from the second scan", func() {
+			top := TopProcesses{Metric: TopByCpu, N: 2}
+			Expect(top.Get()).To(Succeed())
+			Expect(top.List).To(BeEmpty())
+
+			time.Sleep(20 * time.Millisecond)
+			procSetup(100, "small", 50, 100, 0, 3)
+			procSetup(300, "medium", 31, 500, 2048, 7)
+			Expect(os.RemoveAll(procd + "/200")).To(Succeed())
+
+			Expect(top.Get()).To(Succeed())
+			Expect(top.List).To(HaveLen(2))
+			Expect(top.List[0].Pid).To(Equal(100))
+			Expect(top.List[1].Pid).To(Equal(300))
+			Expect(top.List[0].Value).To(BeNumerically(">", top.List[1].Value))
+			Expect(top.cpu.last).To(HaveLen(2))
+		})
+	})
+
+	Describe("ProcSnapshot", func() {
+		procSetup := func(pid int, name string) {
+			dir := procd + "/" + strconv.Itoa(pid)
+			procStatSetup(pid, map[string]string{"comm": name})
+			setupFile(dir+"/status", fmt.Sprintf("Name:\t%s\nThreads:\t2\n", name))
+			setupFile(dir+"/cmdline", "/usr/bin/"+name+"\x00-v\x00")
+			setupFile(dir+"/io", "rchar: 100\nwchar: 200\n")
+		}
+
+		BeforeEach(func() {
+			for pid := 100; pid < 150; pid++ {
+				procSetup(pid, "worker")
+			}
+		})
+
+		It("gathers the selected facts of all processes", func() {
+			snapshot := ProcSnapshot{Fields: SnapshotStat | SnapshotCmdline | SnapshotIO, Workers: 4}
+			Expect(snapshot.Get(context.Background())).To(Succeed())
+
+			Expect(snapshot.List).To(HaveLen(50))
+			for i, entry := range snapshot.List {
+				Expect(entry.Pid).To(Equal(100 + i))
+				Expect(entry.Err).ToNot(HaveOccurred())
+				Expect(entry.Stat.Name).To(Equal("worker"))
+				Expect(entry.Args.List).To(Equal([]string{"/usr/bin/worker", "-v"}))
+				Expect(entry.IO.Wchar).To(Equal(uint64(200)))
+				Expect(entry.Status.Threads).To(BeZero())
+			}
+		})
+
+		It("skips vanished processes and records other errors", func() {
+			Expect(os.Remove(procd + "/120/stat")).To(Succeed())
+			Expect(os.Remove(procd + "/130/io")).To(Succeed())
+			Expect(os.Mkdir(procd+"/130/io", 0755)).To(Succeed())
+
+			snapshot := ProcSnapshot{Fields: SnapshotStat | SnapshotStatus | SnapshotIO}
+			Expect(snapshot.Get(context.Background())).To(Succeed())
+
+			Expect(snapshot.List).To(HaveLen(49))
+			for _, entry := range snapshot.List {
+				Expect(entry.Pid).ToNot(Equal(120))
+				if entry.Pid == 130 {
+					Expect(entry.Err).To(HaveOccurred())
+					Expect(entry.Status.Threads).To(Equal(2))
+				} else {
+					Expect(entry.Err).ToNot(HaveOccurred())
+				}
+			}
+		})
+
+		It("accepts processes without executable", func() {
+			Expect(os.Symlink("/usr/bin/worker", procd+"/100/exe")).To(Succeed())
+			Expect(os.Symlink("/", procd+"/100/cwd")).To(Succeed())
+			Expect(os.Symlink("/", procd+"/100/root")).To(Succeed())
+
+			snapshot := ProcSnapshot{Fields: SnapshotExe}
+			Expect(snapshot.Get(context.Background())).To(Succeed())
+			Expect(snapshot.List).To(HaveLen(50))
+			Expect(snapshot.List[0].Exe.Name).To(Equal("/usr/bin/worker"))
+
+			// Like kernel threads, 101 has no exe link
+			Expect(snapshot.List[1].Exe.Name).To(BeEmpty())
+			Expect(snapshot.List[1].Err).ToNot(HaveOccurred())
+		})
+
+		It("skips processes which vanish before the executable is read", func() {
+			Expect(os.RemoveAll(procd + "/120")).To(Succeed())
+			Expect(os.Mkdir(procd+"/120", 0755)).To(Succeed())
+
+			snapshot := ProcSnapshot{Fields: SnapshotExe}
+			Expect(snapshot.Get(context.Background())).To(Succeed())
+			Expect(snapshot.List).To(HaveLen(49))
+			for _, entry := range snapshot.List {
+				Expect(entry.Pid).ToNot(Equal(120))
+				Expect(entry.Err).ToNot(HaveOccurred())
+			}
+		})
+
+		It("stops at the context deadline", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			snapshot := ProcSnapshot{Fields: SnapshotAll, Workers: 1}
+			Expect(snapshot.Get(ctx)).To(MatchError(context.Canceled))
+			Expect(len(snapshot.List)).To(BeNumerically("<", 50))
+		})
+	})
+
+	Describe("ProcID", func() {
+		procStat := func(start string) {
+			procStatSetup(4242, map[string]string{"starttime": start})
+			setupFile(procd+"/4242/statm", "30000 512 256 1 0 200 0\n")
+		}
+
+		It("identifies a process by pid and start time", func() {
+			procStat("9876")
+
+			id := ProcID{}
+			Expect(id.Get(4242)).To(Succeed())
+			Expect(id).To(Equal(ProcID{Pid: 4242, StartTime: 9876}))
+			Expect(id.Check()).To(Succeed())
+
+			mem := ProcMem{}
+			Expect(id.Do(mem.Get)).To(Succeed())
+			Expect(mem.Resident).To(Equal(uint64(512 << 12)))
+		})
+
+		It("detects reused pids", func() {
+			procStat("9876")
+			id := ProcID{}
+			Expect(id.Get(4242)).To(Succeed())
+
+			procStat("12345")
+			err := id.Check()
+			var nerr *NoSuchProcessError
+			Expect(errors.As(err, &nerr)).To(BeTrue())
+			Expect(err).To(MatchError(syscall.ESRCH))
+
+			called := false
+			err = id.Do(func(int) error {
+				called = true
+				return nil
+			})
+			Expect(err).To(MatchError(syscall.ESRCH))
+			Expect(called).To(BeFalse())
+
+			Expect(ProcControl{}.WaitID(&id, time.Second)).To(Succeed())
+		})
+
+		It("detects exited processes", func() {
+			id := ProcID{Pid: 4243, StartTime: 1}
+			Expect(id.Check()).To(MatchError(syscall.ESRCH))
+			Expect(id.Get(4243)).To(MatchError(syscall.ESRCH))
+		})
+
+		It("drops the pidfd of the previous process on Get", func() {
+			procStatSetup(os.Getpid(), map[string]string{"comm": "self"})
+			procStat("9876")
+
+			id := ProcID{}
+			Expect(id.Get(os.Getpid())).To(Succeed())
+			if err := id.OpenPidfd(); err != nil {
+				Skip("pidfd not available: " + err.Error())
+			}
+			Expect(id.pidfd).ToNot(BeNil())
+
+			Expect(id.Get(4242)).To(Succeed())
+			Expect(id.pidfd).To(BeNil())
+			Expect(id).To(Equal(ProcID{Pid: 4242, StartTime: 9876}))
+		})
+
+		It("checks the id before acting on the process", func() {
+			procStat("9876")
+			stale := ProcID{Pid: 4242, StartTime: 1}
+			control := ProcControl{}
+
+			Expect(control.SignalID(&stale, 0)).To(MatchError(syscall.ESRCH))
+			Expect(control.ReniceID(&stale, 5)).To(MatchError(syscall.ESRCH))
+			Expect(control.SetAffinityID(&stale, []int{0})).To(MatchError(syscall.ESRCH))
+			Expect(control.SetIOPriorityID(&stale, IOPrioClassIdle, 0)).To(MatchError(syscall.ESRCH))
+		})
+	})
+
+	Describe("ProcTty", func() {
+		procStat := func(pid, pgrp, session, tty, tpgid int) {
+			procStatSetup(pid, map[string]string{
+				"comm": "bash", "pgrp": strconv.Itoa(pgrp), "session": strconv.Itoa(session),
+				"tty_nr": strconv.Itoa(tty), "tpgid": strconv.Itoa(tpgid),
+			})
+		}
+
+		charDevice := func(major, minor uint32) unix.Stat_t {
+			return unix.Stat_t{Mode: syscall.S_IFCHR | 0620, Rdev: unix.Mkdev(major, minor)}
+		}
+
+		BeforeEach(func() {
+			setupFile(procd+"/tty/drivers", `/dev/tty             /dev/tty        5       0 system:/dev/tty
+/dev/console         /dev/console    5       1 system:console
+/dev/ptmx            /dev/ptmx       5       2 system
+/dev/vc/0            /dev/vc/0       4       0 system:vtmaster
+serial               /dev/ttyS       4      64 serial
+pty_slave            /dev/pts      136 0-1048575 pty:slave
+pty_master           /dev/ptm      128 0-1048575 pty:master
+unknown              /dev/tty        4 1-63 console
+`)
+
+			devices := map[string]unix.Stat_t{
+				Devd + "/console": charDevice(5, 1),
+				Devd + "/tty1":    charDevice(4, 1),
+				Devd + "/tty2":    {Mode: syscall.S_IFREG | 0644},
+				Devd + "/tty3":    charDevice(4, 4),
+				Devd + "/ttyS0":   charDevice(4, 64),
+				Devd + "/pts/3":   charDevice(136, 3),
+				Devd + "/pts/300": charDevice(136, 300),
+				Devd + "/ttyX":    charDevice(42, 0),
+			}
+			ttyStat = func(path string, st *unix.Stat_t) error {
+				dev, ok := devices[path]
+				if !ok {
+					return syscall.ENOENT
+				}
+				*st = dev
+				return nil
+			}
+		})
+
+		AfterEach(func() {
+			ttyStat = unix.Stat
+		})
+
+		It("resolves the terminal and process groups", func() {
+			procStat(4242, 4242, 4200, 136<<8|3, 4242)
+
+			tty := ProcTty{}
+			err := tty.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tty).To(Equal(ProcTty{
+				Nr:      34819,
+				Major:   136,
+				Minor:   3,
+				Name:    "pts/3",
+				Session: 4200,
+				Pgrp:    4242,
+				Tpgid:   4242,
+			}))
+			Expect(tty.IsForeground()).To(BeTrue())
+
+			stat := ProcStat{}
+			Expect(stat.Get(4242)).To(Succeed())
+			Expect(stat.ProcTty()).To(Equal(tty))
+		})
+
+		It("decodes minor numbers above 255", func() {
+			procStat(4242, 4242, 4200, 1<<20|136<<8|44, 4000)
+
+			tty := ProcTty{}
+			Expect(tty.Get(4242)).To(Succeed())
+			Expect(tty.Minor).To(Equal(uint32(300)))
+			Expect(tty.Name).To(Equal("pts/300"))
+			Expect(tty.IsForeground()).To(BeFalse())
+		})
+
+		DescribeTable("resolves virtual consoles and serial ports",
+			func(major, minor int, name string) {
+				procStat(4242, 4242, 4242, major<<8|minor, 4242)
+
+				tty := ProcTty{}
+				Expect(tty.Get(4242)).To(Succeed())
+				Expect(tty.Name).To(Equal(name))
+			},
+			Entry("console", 5, 1, "console"),
+			Entry("virtual console", 4, 1, "tty1"),
+			Entry("serial port", 4, 64, "ttyS0"),
+			Entry("missing device", 4, 5, ""),
+			Entry("not a character device", 4, 2, ""),
+			Entry("other device numbers", 4, 3, ""),
+			Entry("unknown driver", 42, 0, ""),
+		)
+
+		It("reloads the drivers only for unknown devices", func() {
+			procStat(4242, 4242, 4242, 136<<8|3, 4242)
+			tty := ProcTty{}
+			Expect(tty.Get(4242)).To(Succeed())
+			Expect(tty.Name).To(Equal("pts/3"))
+
+			setupFile(procd+"/tty/drivers", "custom               /dev/ttyX      42       0 custom\n")
+			Expect(tty.Get(4242)).To(Succeed())
+			Expect(tty.Name).To(Equal("pts/3"))
+
+			procStat(4242, 4242, 4242, 42<<8, 4242)
+			Expect(tty.Get(4242)).To(Succeed())
+			Expect(tty.Name).To(Equal("ttyX"))
+		})
+
+		It("leaves the name empty without terminal", func() {
+			procStat(4242, 4242, 4242, 0, -1)
+
+			tty := ProcTty{}
+			Expect(tty.Get(4242)).To(Succeed())
+			Expect(tty.Name).To(BeEmpty())
+			Expect(tty.Tpgid).To(Equal(-1))
+			Expect(tty.IsForeground()).To(BeFalse())
+		})
+	})
+
+	Describe("ProcSecurity", func() {
+		BeforeEach(func() {
+			setupFile(procd+"/4242/status", `Name:	containerd
+Uid:	0	0	0	0
+CapInh:	0000000000000000
+CapPrm:	00000000a80425fb
+CapEff:	00000000a80425fb
+CapBnd:	00000000a80425fb
+CapAmb:	0000000000000400
+NoNewPrivs:	1
+Seccomp:	2
+Seccomp_filters:	3
+`)
+			setupFile(procd+"/4242/attr/current", "garden-default (enforce)\n")
+		})
+
+		It("decodes capabilities, seccomp and the LSM label", func() {
+			security := ProcSecurity{}
+			err := security.Get(4242)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(security.Inheritable).To(BeZero())
+			Expect(security.Effective.Has(CapSysAdmin)).To(BeFalse())
+			Expect(security.Effective.Has(CapNetBindService)).To(BeTrue())
+			Expect(security.Effective.List()).To(Equal([]Capability{
+				CapChown, CapDacOverride, CapFowner, CapFsetid, CapKill,
+				CapSetgid, CapSetuid, CapSetpcap, CapNetBindService, CapNetRaw,
+				CapSysChroot, CapMknod, CapAuditWrite, CapSetfcap,
+			}))
+			Expect(security.Ambient.String()).To(Equal("cap_net_bind_service"))
+			Expect(security.NoNewPrivs).To(BeTrue())
+			Expect(security.Seccomp).To(Equal(SeccompFilter))
+			Expect(security.Seccomp.String()).To(Equal("filter"))
+			Expect(security.SeccompFilters).To(Equal(3))
+			Expect(security.Label).To(Equal("garden-default (enforce)"))
+		})
+
+		It("leaves the label empty without LSM", func() {
+			Expect(os.Remove(procd + "/4242/attr/current")).To(Succeed())
+
+			security := ProcSecurity{Label: "stale"}
+			Expect(security.Get(4242)).To(Succeed())
+			Expect(security.Label).To(BeEmpty())
+		})
+
+		It("returns other errors reading the label", func() {
+			Expect(os.Remove(procd + "/4242/attr/current")).To(Succeed())
+			Expect(os.Mkdir(procd+"/4242/attr/current", 0755)).To(Succeed())
+
+			security := ProcSecurity{}
+			Expect(security.Get(4242)).To(MatchError(syscall.EISDIR))
+		})
+
+		It("maps permission errors reading the label to PermissionError", func() {
+			if os.Geteuid() == 0 {
+				Skip("root can read files without permissions")
+			}
+			Expect(os.Chmod(procd+"/4242/attr/current", 0)).To(Succeed())
+
+			security := ProcSecurity{}
+			err := security.Get(4242)
+			var permErr *PermissionError
+			Expect(errors.As(err, &permErr)).To(BeTrue())
+			Expect(permErr.Name).To(Equal("attr/current"))
+		})
+
+		It("names capabilities", func() {
+			Expect(CapSysAdmin.String()).To(Equal("cap_sys_admin"))
+			Expect(CapCheckpointRestore.String()).To(Equal("cap_checkpoint_restore"))
+			Expect(Capability(45).String()).To(Equal("cap_45"))
+
+			set := CapabilitySet(1<<CapSysAdmin | 1<<CapBpf | 1<<45)
+			Expect(set.String()).To(Equal("cap_sys_admin,cap_bpf,cap_45"))
+		})
+
+		It("returns ESRCH for missing processes", func() {
+			security := ProcSecurity{}
+			Expect(security.Get(4243)).To(MatchError(syscall.ESRCH))
+		})
+	})
 })
@@ -0,0 +1,151 @@
+//go:build !linux
+
+package sigar
+
+import (
+	"syscall"
+)
+
+func (il *NetIfaceList) Get() error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (il *NetIfaceList) GetForPid(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (nl *NetNamespaceList) Get() error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (nl *NetNamespaceList) GetForPids(pids []int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (s *SockStat) Get() error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (s *SockStat) GetForPid(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (rl *NetRouteList) Get() error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (nl *NetNeighborList) Get() error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (s *ProcStat) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (s *ProcStat) ProcState() ProcState {
+	return ProcState{}
+}
+
+func (s *ProcStat) ProcTime() ProcTime {
+	return ProcTime{}
+}
+
+func (s *ProcStat) ProcTty() ProcTty {
+	return ProcTty{}
+}
+
+func (ps *ProcStatus) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (pc *ProcCred) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (pio *ProcIO) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (tl *ProcThreadList) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (pe *ProcEnv) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (pm *ProcMemDetail) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (ml *ProcMapList) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (ml *ProcMapList) GetWithUsage(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (fl *ProcFdList) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (fc *ProcFdCount) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (pl *ProcLimits) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (lu *ProcLimitsUsage) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (pc *ProcCgroups) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (pn *ProcNamespaces) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (c ProcControl) Signal(pid int, sig syscall.Signal) error {
+	return ErrNotImplemented
+}
+
+func (c ProcControl) Renice(pid int, nice int) error {
+	return ErrNotImplemented
+}
+
+func (c ProcControl) SetAffinity(pid int, cpus []int) error {
+	return ErrNotImplemented
+}
+
+func (c ProcControl) SetIOPriority(pid int, class IOPrioClass, level int) error {
+	return ErrNotImplemented
+}
+
+func (ps *ProcSched) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (id *ProcID) OpenPidfd() error {
+	return ErrNotImplemented
+}
+
+func (c ProcControl) signalPidfd(id *ProcID, sig syscall.Signal) error {
+	return ErrNotImplemented
+}
+
+func procGone(pid int) bool {
+	return false
+}
+
+func (t *ProcTty) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
+
+func (ps *ProcSecurity) Get(pid int) error { //nolint:staticcheck
+	return ErrNotImplemented
+}
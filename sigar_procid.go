@@ -0,0 +1,75 @@
+package sigar
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// ProcID identifies a process by pid and start time, which unlike the
+// pid alone is not reused by the kernel. StartTime is in clock ticks
+// since boot, as in ProcStat.
+type ProcID struct {
+	Pid       int
+	StartTime uint64
+
+	pidfd *os.File
+}
+
+func (id *ProcID) Get(pid int) error { //nolint:staticcheck
+	// An open pidfd refers to the previous process
+	id.Close() //nolint:errcheck
+
+	stat := ProcStat{}
+	if err := stat.Get(pid); err != nil {
+		return err
+	}
+
+	id.Pid = pid
+	id.StartTime = stat.StartTime
+
+	return nil
+}
+
+// Check returns a NoSuchProcessError if the process exited or its pid
+// now belongs to another process.
+func (id *ProcID) Check() error {
+	_, err := id.check()
+	return err
+}
+
+func (id *ProcID) check() (ProcStat, error) {
+	stat := ProcStat{}
+	err := stat.Get(id.Pid)
+	if errors.Is(err, syscall.ESRCH) || (err == nil && stat.StartTime != id.StartTime) {
+		return stat, &NoSuchProcessError{Pid: id.Pid, Op: "check"}
+	}
+
+	return stat, err
+}
+
+// Do calls f with the pid, e.g. a getter like ProcMem.Get, and checks
+// id before and after, so the result belongs to the process of id.
+func (id *ProcID) Do(f func(pid int) error) error {
+	if err := id.Check(); err != nil {
+		return err
+	}
+
+	if err := f(id.Pid); err != nil {
+		return err
+	}
+
+	return id.Check()
+}
+
+// Close closes the pidfd opened by OpenPidfd, if any.
+func (id *ProcID) Close() error {
+	if id.pidfd == nil {
+		return nil
+	}
+
+	err := id.pidfd.Close()
+	id.pidfd = nil
+
+	return err
+}
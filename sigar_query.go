@@ -0,0 +1,303 @@
+package sigar
+
+import (
+	"errors"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ProcQuery selects processes with pgrep(1) like predicates, e.g.
+//
+//	q := ProcQuery{}
+//	pids, err := q.Name(regexp.MustCompile("^nginx")).User("vcap").Find()
+//
+// All predicates must match. They are evaluated lazily, cheapest
+// first, so only the files needed to rule a process out are read.
+// Processes which exit or are not readable during evaluation do not
+// match.
+type ProcQuery struct {
+	predicates []procPredicate
+}
+
+// Predicate costs, each level reads one more file per process.
+const (
+	procCostStat = iota
+	procCostStatus
+	procCostLink
+	procCostCgroup
+	procCostCmdline
+)
+
+type procPredicate struct {
+	cost  int
+	match func(p *procQueryTarget) (bool, error)
+}
+
+// procQueryTarget caches what has been read about one process while
+// its predicates are evaluated.
+type procQueryTarget struct {
+	pid int
+
+	stat    *ProcStat
+	statErr error
+	state   *ProcState
+	cred    *ProcCred
+}
+
+func (q *ProcQuery) add(cost int, match func(p *procQueryTarget) (bool, error)) *ProcQuery {
+	q.predicates = append(q.predicates, procPredicate{cost: cost, match: match})
+	return q
+}
+
+// Name matches the process name, which the kernel truncates to 15
+// characters.
+func (q *ProcQuery) Name(re *regexp.Regexp) *ProcQuery {
+	return q.add(procCostStat, func(p *procQueryTarget) (bool, error) {
+		state, err := p.procState()
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(state.Name), nil
+	})
+}
+
+// Cmdline matches the full command line, arguments joined by spaces.
+func (q *ProcQuery) Cmdline(re *regexp.Regexp) *ProcQuery {
+	return q.add(procCostCmdline, func(p *procQueryTarget) (bool, error) {
+		args := ProcArgs{}
+		if err := args.Get(p.pid); err != nil {
+			return false, err
+		}
+		return re.MatchString(strings.Join(args.List, " ")), nil
+	})
+}
+
+// Exe matches the absolute path of the executable.
+func (q *ProcQuery) Exe(path string) *ProcQuery {
+	return q.add(procCostLink, func(p *procQueryTarget) (bool, error) {
+		exe := ProcExe{}
+		if err := exe.Get(p.pid); err != nil {
+			return false, err
+		}
+		return exe.Name == path, nil
+	})
+}
+
+// Uid matches the effective user id.
+func (q *ProcQuery) Uid(uid int) *ProcQuery {
+	return q.add(procCostStatus, func(p *procQueryTarget) (bool, error) {
+		cred, err := p.procCred()
+		if err != nil {
+			return false, err
+		}
+		return cred.Euid == uid, nil
+	})
+}
+
+// User matches the name of the effective user.
+func (q *ProcQuery) User(name string) *ProcQuery {
+	return q.add(procCostStatus, func(p *procQueryTarget) (bool, error) {
+		cred, err := p.procCred()
+		if err != nil {
+			return false, err
+		}
+		return cred.User == name, nil
+	})
+}
+
+func (q *ProcQuery) Ppid(ppid int) *ProcQuery {
+	return q.add(procCostStat, func(p *procQueryTarget) (bool, error) {
+		state, err := p.procState()
+		if err != nil {
+			return false, err
+		}
+		return state.Ppid == ppid, nil
+	})
+}
+
+func (q *ProcQuery) Session(session int) *ProcQuery {
+	return q.add(procCostStat, func(p *procQueryTarget) (bool, error) {
+		stat, err := p.procStat()
+		if err != nil {
+			return false, err
+		}
+		return stat.Session == session, nil
+	})
+}
+
+// CgroupPath matches processes in the cgroup at path or below it, in
+// any hierarchy.
+func (q *ProcQuery) CgroupPath(path string) *ProcQuery {
+	prefix := strings.TrimSuffix(path, "/") + "/"
+
+	return q.add(procCostCgroup, func(p *procQueryTarget) (bool, error) {
+		cgroups := ProcCgroups{}
+		if err := cgroups.Get(p.pid); err != nil {
+			return false, err
+		}
+		for _, cgroup := range cgroups.List {
+			if cgroup.Path == path || strings.HasPrefix(cgroup.Path, prefix) {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// State matches any of the given run states.
+func (q *ProcQuery) State(states ...RunState) *ProcQuery {
+	return q.add(procCostStat, func(p *procQueryTarget) (bool, error) {
+		state, err := p.procState()
+		if err != nil {
+			return false, err
+		}
+		for _, s := range states {
+			if state.State == s {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// MinAge matches processes started at least age ago.
+func (q *ProcQuery) MinAge(age time.Duration) *ProcQuery {
+	return q.add(procCostStat, func(p *procQueryTarget) (bool, error) {
+		ptime, err := p.procTime()
+		if err != nil {
+			return false, err
+		}
+		started := time.UnixMilli(int64(ptime.StartTime))
+		return time.Since(started) >= age, nil
+	})
+}
+
+// Match reports whether pid satisfies all predicates.
+func (q *ProcQuery) Match(pid int) (bool, error) {
+	return q.match(&procQueryTarget{pid: pid}, q.sorted())
+}
+
+// Find returns the matching processes in ascending order.
+func (q *ProcQuery) Find() ([]int, error) {
+	pids := ProcList{}
+	if err := pids.Get(); err != nil {
+		return nil, err
+	}
+
+	predicates := q.sorted()
+
+	var found []int
+	for _, pid := range pids.List {
+		ok, err := q.match(&procQueryTarget{pid: pid}, predicates)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			found = append(found, pid)
+		}
+	}
+	sort.Ints(found)
+
+	return found, nil
+}
+
+// sorted returns the predicates cheapest first.
+func (q *ProcQuery) sorted() []procPredicate {
+	predicates := make([]procPredicate, len(q.predicates))
+	copy(predicates, q.predicates)
+	sort.SliceStable(predicates, func(i, j int) bool {
+		return predicates[i].cost < predicates[j].cost
+	})
+	return predicates
+}
+
+func (q *ProcQuery) match(target *procQueryTarget, predicates []procPredicate) (bool, error) {
+	for _, predicate := range predicates {
+		ok, err := predicate.match(target)
+		if err != nil {
+			if procVanished(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// procVanished reports errors which rule a single process out rather
+// than fail the whole query.
+func procVanished(err error) bool {
+	return errors.Is(err, syscall.ESRCH) ||
+		errors.Is(err, fs.ErrNotExist) ||
+		errors.Is(err, fs.ErrPermission)
+}
+
+func (p *procQueryTarget) procStat() (*ProcStat, error) {
+	if p.stat == nil && p.statErr == nil {
+		stat := ProcStat{}
+		if err := stat.Get(p.pid); err != nil {
+			p.statErr = err
+		} else {
+			p.stat = &stat
+		}
+	}
+	return p.stat, p.statErr
+}
+
+// procState prefers the single read of ProcStat where implemented.
+func (p *procQueryTarget) procState() (ProcState, error) {
+	if p.state != nil {
+		return *p.state, nil
+	}
+
+	stat, err := p.procStat()
+	if err == nil {
+		state := stat.ProcState()
+		p.state = &state
+		return state, nil
+	}
+	if !errors.Is(err, ErrNotImplemented) {
+		return ProcState{}, err
+	}
+
+	state := ProcState{}
+	if err := state.Get(p.pid); err != nil {
+		return state, err
+	}
+	p.state = &state
+	return state, nil
+}
+
+func (p *procQueryTarget) procTime() (ProcTime, error) {
+	stat, err := p.procStat()
+	if err == nil {
+		return stat.ProcTime(), nil
+	}
+	if !errors.Is(err, ErrNotImplemented) {
+		return ProcTime{}, err
+	}
+
+	ptime := ProcTime{}
+	err = ptime.Get(p.pid)
+	return ptime, err
+}
+
+func (p *procQueryTarget) procCred() (ProcCred, error) {
+	if p.cred != nil {
+		return *p.cred, nil
+	}
+
+	cred := ProcCred{}
+	if err := cred.Get(p.pid); err != nil {
+		return cred, err
+	}
+	p.cred = &cred
+	return cred, nil
+}
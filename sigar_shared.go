@@ -1,9 +1,15 @@
 package sigar
 
 import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
 	"time"
 )
 
+// Get sleeps 100ms on the first call for a pid and keeps every pid
+// seen. Use a ProcCpuSampler to sample many processes repeatedly.
 func (pc *ProcCpu) Get(pid int) error { //nolint:staticcheck
 	if pc.cache == nil {
 		pc.cache = make(map[int]ProcCpu)
@@ -30,3 +36,333 @@ func (pc *ProcCpu) Get(pid int) error { //nolint:staticcheck
 	pc.Percent = float64(pc.Total-prevProcCpu.Total) / float64(pc.LastTime-prevProcCpu.LastTime)
 	return nil
 }
+
+// ProcCpuSampler computes the CPU usage of processes from successive
+// samples. Unlike ProcCpu.Get it never sleeps. Samples are tied to the
+// process start time, so a reused pid starts over instead of being
+// compared to its predecessor. It is safe for concurrent use.
+type ProcCpuSampler struct {
+	mu   sync.Mutex
+	last map[int]procCpuSample
+}
+
+type procCpuSample struct {
+	cpu   ProcCpu
+	start uint64
+}
+
+// Sample reads the CPU times of pid and returns the usage since the
+// previous sample of the same process. ok is false, and Percent 0,
+// for the first sample. Processes which cannot be read are evicted.
+func (s *ProcCpuSampler) Sample(pid int) (cpu ProcCpu, ok bool, err error) {
+	ptime, start, err := procTimes(pid)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		delete(s.last, pid)
+		return ProcCpu{}, false, err
+	}
+
+	if s.last == nil {
+		s.last = make(map[int]procCpuSample)
+	}
+
+	cpu.ProcTime = ptime
+	cpu.LastTime = uint64(time.Now().UnixNano() / int64(time.Millisecond))
+
+	prev, found := s.last[pid]
+	s.last[pid] = procCpuSample{cpu: cpu, start: start}
+
+	if !found || prev.start != start {
+		return cpu, false, nil
+	}
+
+	if cpu.LastTime > prev.cpu.LastTime {
+		cpu.Percent = float64(counterDelta(cpu.Total, prev.cpu.Total)) / float64(cpu.LastTime-prev.cpu.LastTime)
+	}
+
+	return cpu, true, nil
+}
+
+// Retain evicts the samples of all pids not in the list, e.g. the
+// exited processes missing from a fresh ProcList.
+func (s *ProcCpuSampler) Retain(pids []int) {
+	keep := make(map[int]bool, len(pids))
+	for _, pid := range pids {
+		keep[pid] = true
+	}
+
+	s.mu.Lock()
+	for pid := range s.last {
+		if !keep[pid] {
+			delete(s.last, pid)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// ProcIOSampler computes per-process I/O rates from successive
+// ProcIO samples. It is safe for concurrent use.
+type ProcIOSampler struct {
+	mu   sync.Mutex
+	last map[int]procIOSample
+}
+
+type procIOSample struct {
+	io    ProcIO
+	start uint64
+	time  time.Time
+}
+
+// Sample reads the I/O counters of pid and returns the rate since the
+// previous sample of the same process. ok is false for the first
+// sample, also after the pid was reused by another process.
+func (s *ProcIOSampler) Sample(pid int) (rate ProcIORate, ok bool, err error) {
+	cur := procIOSample{time: time.Now()}
+	_, cur.start, err = procTimes(pid)
+	if err == nil {
+		err = cur.io.Get(pid)
+	}
+	if err != nil {
+		s.mu.Lock()
+		delete(s.last, pid)
+		s.mu.Unlock()
+		return ProcIORate{}, false, err
+	}
+
+	s.mu.Lock()
+	if s.last == nil {
+		s.last = make(map[int]procIOSample)
+	}
+	prev, found := s.last[pid]
+	s.last[pid] = cur
+	s.mu.Unlock()
+
+	if !found || prev.start != cur.start {
+		return ProcIORate{Pid: pid}, false, nil
+	}
+
+	return procIODelta(pid, prev, cur), true, nil
+}
+
+// Collect samples pids twice, interval apart, in the manner of
+// `iotop -b -n 1`. Processes which cannot be read, exited or other
+// users' processes, are skipped. The result is sorted by the sum of
+// read and write bytes, busiest first.
+func (s *ProcIOSampler) Collect(pids []int, interval time.Duration) []ProcIORate {
+	for _, pid := range pids {
+		s.Sample(pid) //nolint:errcheck
+	}
+
+	time.Sleep(interval)
+
+	// Samples of pids which failed are evicted by Sample, others may
+	// belong to concurrent callers and are left to Retain.
+	rates := make([]ProcIORate, 0, len(pids))
+	for _, pid := range pids {
+		rate, ok, err := s.Sample(pid)
+		if err != nil || !ok {
+			continue
+		}
+		rates = append(rates, rate)
+	}
+
+	sort.SliceStable(rates, func(i, j int) bool {
+		return rates[i].ReadBytes+rates[i].WriteBytes > rates[j].ReadBytes+rates[j].WriteBytes
+	})
+
+	return rates
+}
+
+// Retain evicts the samples of all pids not in the list, e.g. the
+// exited processes missing from a fresh ProcList.
+func (s *ProcIOSampler) Retain(pids []int) {
+	keep := make(map[int]bool, len(pids))
+	for _, pid := range pids {
+		keep[pid] = true
+	}
+
+	s.mu.Lock()
+	for pid := range s.last {
+		if !keep[pid] {
+			delete(s.last, pid)
+		}
+	}
+	s.mu.Unlock()
+}
+
+func procIODelta(pid int, prev, cur procIOSample) ProcIORate {
+	rate := ProcIORate{
+		Pid:        pid,
+		Interval:   cur.time.Sub(prev.time),
+		ReadBytes:  counterDelta(cur.io.ReadBytes, prev.io.ReadBytes),
+		WriteBytes: counterDelta(cur.io.WriteBytes, prev.io.WriteBytes),
+		Rchar:      counterDelta(cur.io.Rchar, prev.io.Rchar),
+		Wchar:      counterDelta(cur.io.Wchar, prev.io.Wchar),
+	}
+
+	if seconds := rate.Interval.Seconds(); seconds > 0 {
+		rate.ReadBytesPerSec = float64(rate.ReadBytes) / seconds
+		rate.WriteBytesPerSec = float64(rate.WriteBytes) / seconds
+	}
+
+	return rate
+}
+
+// procTimes reads the CPU times of pid along with its start time,
+// which tells a reused pid apart: clock ticks since boot from ProcStat,
+// or ProcTime.StartTime where ProcStat is not implemented.
+func procTimes(pid int) (ProcTime, uint64, error) {
+	stat := ProcStat{}
+	err := stat.Get(pid)
+	if err == nil {
+		return stat.ProcTime(), stat.StartTime, nil
+	}
+	if !errors.Is(err, ErrNotImplemented) {
+		return ProcTime{}, 0, err
+	}
+
+	ptime := ProcTime{}
+	err = ptime.Get(pid)
+	return ptime, ptime.StartTime, err
+}
+
+// counterDelta returns cur - prev, or 0 if the counter went backwards,
+// e.g. because the pid was reused.
+func counterDelta(cur, prev uint64) uint64 {
+	if cur < prev {
+		return 0
+	}
+	return cur - prev
+}
+
+// ProcThreadCpuSampler computes the CPU usage of the threads of a
+// process from successive samples. Like ProcCpuSampler the samples are
+// tied to the process start time. It is safe for concurrent use.
+type ProcThreadCpuSampler struct {
+	mu   sync.Mutex
+	last map[int]procThreadSamples
+}
+
+type procThreadSamples struct {
+	start   uint64
+	threads map[int]procThreadSample
+}
+
+type procThreadSample struct {
+	total    uint64
+	lastTime uint64
+}
+
+// Sample lists the threads of pid with their CPU usage since the
+// previous Sample of the same process. Percent is 0 for threads seen
+// for the first time, also after the pid was reused.
+func (s *ProcThreadCpuSampler) Sample(pid int) ([]ProcThreadCpu, error) {
+	threads := ProcThreadList{}
+	_, start, err := procTimes(pid)
+	if err == nil {
+		err = threads.Get(pid)
+	}
+	now := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		delete(s.last, pid)
+		return nil, err
+	}
+
+	if s.last == nil {
+		s.last = make(map[int]procThreadSamples)
+	}
+	prev := s.last[pid].threads
+	if s.last[pid].start != start {
+		prev = nil
+	}
+
+	cur := make(map[int]procThreadSample, len(threads.List))
+	list := make([]ProcThreadCpu, 0, len(threads.List))
+
+	for _, thread := range threads.List {
+		sample := procThreadSample{total: thread.Total, lastTime: now}
+		cur[thread.Tid] = sample
+
+		tc := ProcThreadCpu{ProcThread: thread}
+		if p, ok := prev[thread.Tid]; ok && now > p.lastTime {
+			tc.Percent = float64(counterDelta(sample.total, p.total)) / float64(now-p.lastTime)
+		}
+		list = append(list, tc)
+	}
+
+	// Replacing the map drops exited threads
+	s.last[pid] = procThreadSamples{start: start, threads: cur}
+
+	return list, nil
+}
+
+// Retain evicts the samples of all pids not in the list.
+func (s *ProcThreadCpuSampler) Retain(pids []int) {
+	keep := make(map[int]bool, len(pids))
+	for _, pid := range pids {
+		keep[pid] = true
+	}
+
+	s.mu.Lock()
+	for pid := range s.last {
+		if !keep[pid] {
+			delete(s.last, pid)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// AppTags looks up the variables Cloud Foundry sets for application
+// instances: VCAP_APPLICATION, then CF_INSTANCE_* and INSTANCE_* for
+// the instance identity. found is false if none of them is present.
+func (pe *ProcEnv) AppTags() (tags ProcAppTags, found bool) {
+	if raw, ok := pe.Vars["VCAP_APPLICATION"]; ok {
+		var app struct {
+			ApplicationId    string      `json:"application_id"`
+			ApplicationName  string      `json:"application_name"`
+			SpaceId          string      `json:"space_id"`
+			SpaceName        string      `json:"space_name"`
+			OrganizationId   string      `json:"organization_id"`
+			OrganizationName string      `json:"organization_name"`
+			InstanceId       string      `json:"instance_id"`
+			InstanceIndex    json.Number `json:"instance_index"`
+		}
+
+		if err := json.Unmarshal([]byte(raw), &app); err == nil {
+			found = true
+			tags.AppGuid = app.ApplicationId
+			tags.AppName = app.ApplicationName
+			tags.SpaceGuid = app.SpaceId
+			tags.SpaceName = app.SpaceName
+			tags.OrgGuid = app.OrganizationId
+			tags.OrgName = app.OrganizationName
+			tags.InstanceGuid = app.InstanceId
+			tags.InstanceIndex = app.InstanceIndex.String()
+		}
+	}
+
+	lookup := func(field *string, names ...string) {
+		if *field != "" {
+			return
+		}
+		for _, name := range names {
+			if val, ok := pe.Vars[name]; ok && val != "" {
+				*field = val
+				found = true
+				return
+			}
+		}
+	}
+
+	lookup(&tags.InstanceGuid, "CF_INSTANCE_GUID", "INSTANCE_GUID")
+	lookup(&tags.InstanceIndex, "CF_INSTANCE_INDEX", "INSTANCE_INDEX")
+
+	return tags, found
+}
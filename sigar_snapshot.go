@@ -0,0 +1,163 @@
+package sigar
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"runtime"
+	"sort"
+	"sync"
+	"syscall"
+)
+
+// ProcSnapshotFields selects the facts a ProcSnapshot gathers.
+type ProcSnapshotFields uint
+
+const (
+	SnapshotStat ProcSnapshotFields = 1 << iota
+	SnapshotStatus
+	SnapshotCmdline
+	SnapshotExe
+	SnapshotIO
+	SnapshotFds
+
+	SnapshotAll = SnapshotStat | SnapshotStatus | SnapshotCmdline |
+		SnapshotExe | SnapshotIO | SnapshotFds
+)
+
+// ProcSnapshotEntry holds the facts gathered for one process. Only the
+// selected fields are set. Err joins the errors of facts which could
+// not be read, typically PermissionErrors for other users' processes.
+type ProcSnapshotEntry struct {
+	Pid    int
+	Stat   ProcStat
+	Status ProcStatus
+	Args   ProcArgs
+	Exe    ProcExe
+	IO     ProcIO
+	Fds    ProcFdCount
+	Err    error
+}
+
+// ProcSnapshot gathers facts about all processes with a pool of
+// Workers goroutines, runtime.NumCPU() by default. Processes which
+// exit during the scan are left out.
+type ProcSnapshot struct {
+	Fields  ProcSnapshotFields
+	Workers int
+	List    []ProcSnapshotEntry
+}
+
+// Get scans all processes. If ctx expires first, List holds the
+// processes scanned so far and the context error is returned.
+func (s *ProcSnapshot) Get(ctx context.Context) error {
+	pids := ProcList{}
+	if err := pids.Get(); err != nil {
+		return err
+	}
+
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	entries := make([]ProcSnapshotEntry, len(pids.List))
+	found := make([]bool, len(pids.List))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				entry, err := s.entry(pids.List[i])
+				if errors.Is(err, syscall.ESRCH) {
+					continue
+				}
+				if errors.Is(err, ErrNotImplemented) {
+					cancel(err)
+					continue
+				}
+				entry.Err = err
+				entries[i] = entry
+				found[i] = true
+			}
+		}()
+	}
+
+feed:
+	for i := range pids.List {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	s.List = s.List[:0]
+	for i, entry := range entries {
+		if found[i] {
+			s.List = append(s.List, entry)
+		}
+	}
+	sort.Slice(s.List, func(i, j int) bool {
+		return s.List[i].Pid < s.List[j].Pid
+	})
+
+	return context.Cause(ctx)
+}
+
+// entry reads the selected facts of pid. It returns ESRCH as soon as
+// the process is found to be gone, other errors are joined.
+func (s *ProcSnapshot) entry(pid int) (ProcSnapshotEntry, error) {
+	entry := ProcSnapshotEntry{Pid: pid}
+
+	facts := []struct {
+		field ProcSnapshotFields
+		name  string
+		get   func(int) error
+	}{
+		{SnapshotStat, "stat", entry.Stat.Get},
+		{SnapshotStatus, "status", entry.Status.Get},
+		{SnapshotCmdline, "cmdline", entry.Args.Get},
+		{SnapshotExe, "exe", entry.Exe.Get},
+		{SnapshotIO, "io", entry.IO.Get},
+		{SnapshotFds, "fd", entry.Fds.Get},
+	}
+
+	var errs []error
+	for _, fact := range facts {
+		if s.Fields&fact.field == 0 {
+			continue
+		}
+
+		err := fact.get(pid)
+		switch {
+		case err == nil:
+		case errors.Is(err, syscall.ESRCH), errors.Is(err, ErrNotImplemented):
+			return entry, err
+		case fact.field == SnapshotExe && errors.Is(err, fs.ErrNotExist):
+			// Kernel threads have no executable, a process which exited
+			// has no files at all
+			if procGone(pid) {
+				return entry, syscall.ESRCH
+			}
+		case errors.Is(err, fs.ErrPermission):
+			var perr *PermissionError
+			if !errors.As(err, &perr) {
+				err = &PermissionError{Pid: pid, Name: fact.name, Err: err}
+			}
+			errs = append(errs, err)
+		default:
+			errs = append(errs, err)
+		}
+	}
+
+	return entry, errors.Join(errs...)
+}
@@ -0,0 +1,153 @@
+package sigar
+
+import (
+	"errors"
+	"sort"
+)
+
+// TopMetric selects what TopProcesses ranks processes by.
+type TopMetric int
+
+const (
+	// TopByCpu ranks by CPU usage as a fraction of one cpu
+	TopByCpu TopMetric = iota
+	// TopByRss ranks by resident memory in bytes
+	TopByRss
+	// TopByPss ranks by proportional set size in bytes
+	TopByPss
+	// TopByIO ranks by bytes read and written per second
+	TopByIO
+	// TopBySwap ranks by swapped out memory in bytes
+	TopBySwap
+	// TopByFds ranks by the number of open file descriptors
+	TopByFds
+)
+
+func (m TopMetric) String() string {
+	switch m {
+	case TopByCpu:
+		return "cpu"
+	case TopByRss:
+		return "rss"
+	case TopByPss:
+		return "pss"
+	case TopByIO:
+		return "io"
+	case TopBySwap:
+		return "swap"
+	case TopByFds:
+		return "fds"
+	default:
+		return "unknown"
+	}
+}
+
+// IsRate reports whether the metric is computed between two scans.
+func (m TopMetric) IsRate() bool {
+	return m == TopByCpu || m == TopByIO
+}
+
+type TopProcess struct {
+	Pid   int
+	Name  string
+	Value float64
+}
+
+// TopProcesses lists the N heaviest processes by Metric, heaviest
+// first. Rate metrics are computed between successive calls of Get,
+// so the first Get returns an empty list; call Get on an interval and
+// reuse the value. Processes which cannot be read are skipped. It is
+// not safe for concurrent use.
+type TopProcesses struct {
+	Metric TopMetric
+	N      int
+	List   []TopProcess
+
+	pids ProcList
+	scan []TopProcess
+	cpu  ProcCpuSampler
+	io   ProcIOSampler
+}
+
+func (t *TopProcesses) Get() error { //nolint:staticcheck
+	if err := t.pids.Get(); err != nil {
+		return err
+	}
+
+	// The scan buffer is reused across calls
+	t.scan = t.scan[:0]
+
+	for _, pid := range t.pids.List {
+		value, ok, err := t.value(pid)
+		if errors.Is(err, ErrNotImplemented) {
+			return err
+		}
+		if err != nil || !ok {
+			continue
+		}
+		t.scan = append(t.scan, TopProcess{Pid: pid, Value: value})
+	}
+
+	switch t.Metric {
+	case TopByCpu:
+		t.cpu.Retain(t.pids.List)
+	case TopByIO:
+		t.io.Retain(t.pids.List)
+	}
+
+	sort.Slice(t.scan, func(i, j int) bool {
+		if t.scan[i].Value != t.scan[j].Value {
+			return t.scan[i].Value > t.scan[j].Value
+		}
+		return t.scan[i].Pid < t.scan[j].Pid
+	})
+
+	n := len(t.scan)
+	if t.N > 0 && t.N < n {
+		n = t.N
+	}
+
+	// Names are only read for the processes listed
+	t.List = t.List[:0]
+	for _, top := range t.scan[:n] {
+		state := ProcState{}
+		if err := state.Get(top.Pid); err != nil {
+			continue
+		}
+		top.Name = state.Name
+		t.List = append(t.List, top)
+	}
+
+	return nil
+}
+
+// value reads the metric of pid, ok is false if a rate metric has no
+// previous sample yet.
+func (t *TopProcesses) value(pid int) (float64, bool, error) {
+	switch t.Metric {
+	case TopByCpu:
+		cpu, ok, err := t.cpu.Sample(pid)
+		return cpu.Percent, ok, err
+	case TopByRss:
+		mem := ProcMem{}
+		err := mem.Get(pid)
+		return float64(mem.Resident), true, err
+	case TopByPss:
+		detail := ProcMemDetail{}
+		err := detail.Get(pid)
+		return float64(detail.Pss), true, err
+	case TopByIO:
+		rate, ok, err := t.io.Sample(pid)
+		return rate.ReadBytesPerSec + rate.WriteBytesPerSec, ok, err
+	case TopBySwap:
+		status := ProcStatus{}
+		err := status.Get(pid)
+		return float64(status.VmSwap), true, err
+	case TopByFds:
+		fds := ProcFdCount{}
+		err := fds.Get(pid)
+		return float64(fds.Count), true, err
+	}
+
+	return 0, false, ErrNotImplemented
+}
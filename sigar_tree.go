@@ -0,0 +1,231 @@
+package sigar
+
+import (
+	"errors"
+	"sort"
+)
+
+// ProcTree is a snapshot of the process hierarchy built from ProcList
+// and ProcState.Ppid.
+type ProcTree struct {
+	states   map[int]ProcState
+	starts   map[int]uint64
+	children map[int][]int
+}
+
+// ProcTreeUsage sums the resource usage of a process and all of its
+// descendants. Rss and Pss are in bytes, CpuTime in milliseconds.
+// Pss and Threads are only available where ProcMemDetail and
+// ProcStatus are implemented, and may undercount processes whose
+// details are not readable.
+type ProcTreeUsage struct {
+	Procs   int
+	Rss     uint64
+	Pss     uint64
+	CpuTime uint64
+	Threads uint64
+}
+
+func (t *ProcTree) Get() error { //nolint:staticcheck
+	pids := ProcList{}
+	if err := pids.Get(); err != nil {
+		return err
+	}
+
+	states := make(map[int]ProcState, len(pids.List))
+	starts := make(map[int]uint64, len(pids.List))
+	for _, pid := range pids.List {
+		// ProcStat also gives the start time where implemented
+		stat := ProcStat{}
+		err := stat.Get(pid)
+		if err == nil {
+			states[pid] = stat.ProcState()
+			starts[pid] = stat.StartTime
+			continue
+		}
+		if !errors.Is(err, ErrNotImplemented) {
+			// The process exited meanwhile
+			continue
+		}
+
+		state := ProcState{}
+		if err := state.Get(pid); err != nil {
+			continue
+		}
+		states[pid] = state
+	}
+
+	t.build(states)
+	t.starts = starts
+
+	return nil
+}
+
+func (t *ProcTree) build(states map[int]ProcState) {
+	children := make(map[int][]int)
+	for pid, state := range states {
+		if pid == state.Ppid {
+			continue
+		}
+		children[state.Ppid] = append(children[state.Ppid], pid)
+	}
+	for _, list := range children {
+		sort.Ints(list)
+	}
+
+	t.states = states
+	t.children = children
+}
+
+// Pids returns all processes in the tree in ascending order.
+func (t *ProcTree) Pids() []int {
+	pids := make([]int, 0, len(t.states))
+	for pid := range t.states {
+		pids = append(pids, pid)
+	}
+	sort.Ints(pids)
+	return pids
+}
+
+// State returns the ProcState the tree was built from.
+func (t *ProcTree) State(pid int) (ProcState, bool) {
+	state, ok := t.states[pid]
+	return state, ok
+}
+
+// ID returns the ProcID of pid as of when the tree was built, which
+// tells whether the pid was reused since. It is only available where
+// ProcStat is implemented.
+func (t *ProcTree) ID(pid int) (ProcID, bool) {
+	start, ok := t.starts[pid]
+	if !ok {
+		return ProcID{}, false
+	}
+	return ProcID{Pid: pid, StartTime: start}, true
+}
+
+// Roots returns the processes whose parent is not in the tree, e.g.
+// init and kthreadd, in ascending order.
+func (t *ProcTree) Roots() []int {
+	var roots []int
+	for _, pid := range t.Pids() {
+		if _, ok := t.states[t.states[pid].Ppid]; !ok || t.states[pid].Ppid == pid {
+			roots = append(roots, pid)
+		}
+	}
+	return roots
+}
+
+func (t *ProcTree) Parent(pid int) (int, bool) {
+	state, ok := t.states[pid]
+	if !ok {
+		return 0, false
+	}
+	if _, ok := t.states[state.Ppid]; !ok || state.Ppid == pid {
+		return 0, false
+	}
+	return state.Ppid, true
+}
+
+func (t *ProcTree) Children(pid int) []int {
+	return t.children[pid]
+}
+
+// Ancestors returns the parent chain of pid, nearest first.
+func (t *ProcTree) Ancestors(pid int) []int {
+	var ancestors []int
+	seen := map[int]bool{pid: true}
+
+	for {
+		ppid, ok := t.Parent(pid)
+		if !ok || seen[ppid] {
+			return ancestors
+		}
+		seen[ppid] = true
+		ancestors = append(ancestors, ppid)
+		pid = ppid
+	}
+}
+
+// Descendants returns all processes below pid in depth-first order.
+func (t *ProcTree) Descendants(pid int) []int {
+	var descendants []int
+	seen := map[int]bool{pid: true}
+
+	var walk func(int)
+	walk = func(pid int) {
+		for _, child := range t.children[pid] {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			descendants = append(descendants, child)
+			walk(child)
+		}
+	}
+	walk(pid)
+
+	return descendants
+}
+
+// Depth returns the number of ancestors of pid, 0 for roots.
+func (t *ProcTree) Depth(pid int) int {
+	return len(t.Ancestors(pid))
+}
+
+// Usage sums the usage of pid and its descendants, e.g. the total
+// memory of a job including all forked workers. Processes which exit
+// while being read are skipped.
+func (t *ProcTree) Usage(pid int) (ProcTreeUsage, error) {
+	usage := ProcTreeUsage{}
+
+	for _, p := range append([]int{pid}, t.Descendants(pid)...) {
+		rss, cpuTime, threads, err := procTreeUsage(p)
+		if err != nil {
+			if p == pid {
+				return usage, err
+			}
+			continue
+		}
+
+		usage.Procs++
+		usage.Rss += rss
+		usage.CpuTime += cpuTime
+		usage.Threads += threads
+
+		detail := ProcMemDetail{}
+		if err := detail.Get(p); err == nil {
+			usage.Pss += detail.Pss
+		}
+	}
+
+	return usage, nil
+}
+
+// procTreeUsage reads the Rss, cpu time and threads of pid, from a
+// single read of ProcStat where implemented.
+func procTreeUsage(pid int) (rss, cpuTime, threads uint64, err error) {
+	stat := ProcStat{}
+	err = stat.Get(pid)
+	if err == nil {
+		return stat.Rss << 12, stat.ProcTime().Total, uint64(stat.NumThreads), nil
+	}
+	if !errors.Is(err, ErrNotImplemented) {
+		return 0, 0, 0, err
+	}
+
+	mem := ProcMem{}
+	if err := mem.Get(pid); err != nil {
+		return 0, 0, 0, err
+	}
+	ptime := ProcTime{}
+	if err := ptime.Get(pid); err != nil {
+		return 0, 0, 0, err
+	}
+	status := ProcStatus{}
+	if err := status.Get(pid); err == nil {
+		threads = uint64(status.Threads)
+	}
+
+	return mem.Resident, ptime.Total, threads, nil
+}